@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Colors of the banner Announce highlights a message with
+const (
+	AnnouncementPrimary = "primary"
+	AnnouncementBlue    = "blue"
+	AnnouncementGreen   = "green"
+	AnnouncementOrange  = "orange"
+	AnnouncementPurple  = "purple"
+)
+
+// SendChatAnnouncement posts message to the chat of broadcasterID as an announcement, highlighted
+// with color. moderatorID is the user sending it, who must be a moderator of the channel, and the
+// token must have the moderator:manage:announcements scope.
+func (h *HelixClient) SendChatAnnouncement(ctx context.Context, broadcasterID, moderatorID, message, color string) error {
+	body := struct {
+		Message string `json:"message"`
+		Color   string `json:"color,omitempty"`
+	}{message, color}
+	query := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+	err := h.do(ctx, http.MethodPost, "/chat/announcements", query, body, nil)
+	return missingScope(err, "moderator:manage:announcements")
+}
+
+// Announce posts message to channel as an announcement, highlighted with a banner of color, one
+// of the Announcement colors. An empty color is AnnouncementPrimary, the channel's accent color.
+//
+// The bot must be a moderator of the channel, and its token must have the
+// moderator:manage:announcements scope.
+func (bb *BasicBot) Announce(channel, message, color string) error {
+	return bb.AnnounceContext(context.Background(), channel, message, color)
+}
+
+// AnnounceContext is like Announce, giving up on the request to Helix once ctx is done
+func (bb *BasicBot) AnnounceContext(ctx context.Context, channel, message, color string) error {
+	if message == "" {
+		return fmt.Errorf("BasicBot.Announce: %w", ErrEmptyMessage)
+	}
+	color = strings.ToLower(color)
+	switch color {
+	case "":
+		color = AnnouncementPrimary
+	case AnnouncementPrimary, AnnouncementBlue, AnnouncementGreen, AnnouncementOrange, AnnouncementPurple:
+	default:
+		return fmt.Errorf("BasicBot.Announce: unknown color %q, expected blue, green, orange, purple or primary", color)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	broadcasterID, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("BasicBot.Announce: %w", err)
+	}
+	moderatorID, err := helix.broadcasterID(ctx, bb.Name)
+	if err != nil {
+		return fmt.Errorf("BasicBot.Announce: %w", err)
+	}
+	if err := helix.SendChatAnnouncement(ctx, broadcasterID, moderatorID, message, color); err != nil {
+		return fmt.Errorf("BasicBot.Announce: %w", err)
+	}
+	return nil
+}
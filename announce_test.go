@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnnounce(t *testing.T) {
+	var posted map[string]string
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			if r.URL.Query().Get("login") == "bot" {
+				w.Write([]byte(`{"data":[{"id":"456","login":"bot"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/chat/announcements":
+			query = r.URL.RawQuery
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL}
+	if err := b.Announce("dallas", "Follow for updates", ""); err != nil {
+		t.Fatal(err)
+	}
+	if query != "broadcaster_id=123&moderator_id=456" {
+		t.Errorf("query %q", query)
+	}
+	if posted["message"] != "Follow for updates" || posted["color"] != AnnouncementPrimary {
+		t.Errorf("posted %v", posted)
+	}
+
+	if err := b.Announce("dallas", "hi", "red"); err == nil {
+		t.Error("announced with an unknown color")
+	}
+}
+
+func TestAnnounceMissingScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Missing scope: moderator:manage:announcements"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL}
+	if err := b.Announce("dallas", "hi", "purple"); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("Announce returned %v, want ErrMissingScope", err)
+	}
+}
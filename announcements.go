@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// announcement is a message said on a schedule by ScheduleAnnouncement
+type announcement struct {
+	channel  string
+	message  string
+	interval time.Duration
+	// seen is how many chat messages had been seen in channel at the last announcement
+	seen uint64
+	// cancelled is closed when the schedule is cancelled
+	cancelled chan struct{}
+}
+
+// announcements are the bot's scheduled announcements, which run while it's connected
+type announcements struct {
+	mu        sync.Mutex
+	scheduled []*announcement
+	// activity counts the chat messages seen in each channel
+	activity map[string]uint64
+	// stop is closed when the current connection is lost, nil while disconnected
+	stop <-chan struct{}
+}
+
+// ScheduleAnnouncement says message in channel every interval while the bot is connected. An
+// announcement is skipped when nobody has chatted in the channel since the last one, so the bot
+// doesn't talk to an empty room. The schedule restarts whenever the bot reconnects.
+//
+// Any number of announcements can be scheduled. Calling the returned function cancels this one.
+func (bb *BasicBot) ScheduleAnnouncement(channel string, interval time.Duration, message string) (func(), error) {
+	if interval <= 0 {
+		return nil, errors.New("BasicBot.ScheduleAnnouncement: interval must be positive")
+	}
+	if message == "" {
+		return nil, fmt.Errorf("BasicBot.ScheduleAnnouncement: %w", ErrEmptyMessage)
+	}
+
+	a := &announcement{
+		channel:   NormalizeChannel(channel),
+		message:   message,
+		interval:  interval,
+		cancelled: make(chan struct{}),
+	}
+
+	bb.announcements.mu.Lock()
+	a.seen = bb.announcements.activity[a.channel]
+	bb.announcements.scheduled = append(bb.announcements.scheduled, a)
+	if stop := bb.announcements.stop; stop != nil {
+		go bb.announce(a, stop)
+	}
+	bb.announcements.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(a.cancelled)
+			bb.announcements.remove(a)
+		})
+	}, nil
+}
+
+// startAnnouncements runs the scheduled announcements until stop is closed
+func (bb *BasicBot) startAnnouncements(stop <-chan struct{}) {
+	bb.announcements.mu.Lock()
+	defer bb.announcements.mu.Unlock()
+
+	bb.announcements.stop = stop
+	for _, a := range bb.announcements.scheduled {
+		go bb.announce(a, stop)
+	}
+}
+
+// stopAnnouncements forgets the connection the announcements ran on, once stop has been closed
+func (bb *BasicBot) stopAnnouncements() {
+	bb.announcements.mu.Lock()
+	defer bb.announcements.mu.Unlock()
+
+	bb.announcements.stop = nil
+}
+
+// announce says a every interval until stop is closed or a is cancelled
+func (bb *BasicBot) announce(a *announcement, stop <-chan struct{}) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-a.cancelled:
+			return
+		case <-ticker.C:
+		}
+
+		if !bb.announcements.due(a) {
+			bb.logger().Debugf("nobody chatted in #%s, skipping an announcement", a.channel)
+			continue
+		}
+		if err := bb.Say(a.channel, a.message); err != nil {
+			bb.logger().Errorf("announcing in #%s: %s", a.channel, err)
+		}
+	}
+}
+
+// chatted counts a chat message in channel towards its activity
+func (s *announcements) chatted(channel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activity == nil {
+		s.activity = make(map[string]uint64)
+	}
+	s.activity[channel]++
+}
+
+// due reports whether anyone chatted in a's channel since it was last announced, marking it
+// announced when they have
+func (s *announcements) due(a *announcement) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.activity[a.channel] == a.seen {
+		return false
+	}
+	a.seen = s.activity[a.channel]
+	return true
+}
+
+func (s *announcements) remove(a *announcement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, scheduled := range s.scheduled {
+		if scheduled == a {
+			s.scheduled = append(s.scheduled[:i], s.scheduled[i+1:]...)
+			return
+		}
+	}
+}
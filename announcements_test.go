@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleAnnouncement(t *testing.T) {
+	conn := newFakeConn(":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :hello\r\n")
+	b := &BasicBot{Channel: "channel", Name: "bot", Logger: NopLogger{}}
+	b.setConn(conn)
+
+	cancelFollow, err := b.ScheduleAnnouncement("channel", 20*time.Millisecond, "Follow for updates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelFollow()
+	cancelQuiet, err := b.ScheduleAnnouncement("quiet", 20*time.Millisecond, "Anyone here?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelQuiet()
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	conn.waitFor(t, "PRIVMSG #channel :Follow for updates\r\n")
+	// nobody chatted since, in either channel
+	time.Sleep(100 * time.Millisecond)
+	b.Disconnect()
+	<-result
+
+	written := conn.written()
+	if n := strings.Count(written, "Follow for updates"); n != 1 {
+		t.Errorf("announced %d times after one chat message, want 1", n)
+	}
+	if strings.Contains(written, "Anyone here?") {
+		t.Error("announced to a channel nobody chatted in")
+	}
+}
+
+func TestScheduleAnnouncementErrors(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	if _, err := b.ScheduleAnnouncement("channel", 0, "hi"); err == nil {
+		t.Error("scheduled with no interval")
+	}
+	if _, err := b.ScheduleAnnouncement("channel", time.Minute, ""); err == nil {
+		t.Error("scheduled an empty message")
+	}
+}
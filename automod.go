@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ModAction is what the bot does to a message breaking an auto-moderation rule
+type ModAction int
+
+// Moderation actions, from mildest to harshest
+const (
+	// ModWarn replies to the message with a warning
+	ModWarn ModAction = iota
+	// ModDelete deletes the message
+	ModDelete
+	// ModTimeout deletes the message by timing its sender out for AutoModTimeout
+	ModTimeout
+	// ModBan bans the sender
+	ModBan
+)
+
+func (a ModAction) String() string {
+	switch a {
+	case ModWarn:
+		return "warn"
+	case ModDelete:
+		return "delete"
+	case ModTimeout:
+		return "timeout"
+	case ModBan:
+		return "ban"
+	}
+	return "unknown"
+}
+
+// LinkPattern matches links in chat messages, for an auto-moderation rule against links
+var LinkPattern = regexp.MustCompile(`(?i)\b(https?://\S+|www\.\S+|[a-z0-9-]+\.(com|net|org|io|gg|tv|ly|me|co|xyz|ru)(/\S*)?\b)`)
+
+const (
+	// DefaultAutoModTimeout is how long ModTimeout times users out for by default
+	DefaultAutoModTimeout = 10 * time.Minute
+	// offenses older than this are forgiven
+	offenseMemory = time.Hour
+)
+
+// autoModRule is a pattern messages mustn't match, and what to do with those that do
+type autoModRule struct {
+	pattern *regexp.Regexp
+	action  ModAction
+}
+
+// offense is a user's recent history of breaking rules
+type offense struct {
+	count int
+	last  time.Time
+}
+
+// autoMod holds the auto-moderation rules and who broke them
+type autoMod struct {
+	mu       sync.Mutex
+	rules    []autoModRule
+	offenses map[string]*offense // channel + " " + user -> offense
+}
+
+// AddAutoModRule makes the bot take action on every chat message matching pattern, for example
+// AddAutoModRule(LinkPattern, ModDelete) to delete links. Moderators and the broadcaster are exempt.
+//
+// Users breaking rules repeatedly within an hour get a harsher action each time, up to a timeout,
+// or a ban if that's what the rule says. The bot must be a moderator to act on anything but ModWarn.
+func (bb *BasicBot) AddAutoModRule(pattern *regexp.Regexp, action ModAction) {
+	bb.autoMod.mu.Lock()
+	defer bb.autoMod.mu.Unlock()
+
+	bb.autoMod.rules = append(bb.autoMod.rules, autoModRule{pattern, action})
+}
+
+// ClearAutoModRules removes every auto-moderation rule
+func (bb *BasicBot) ClearAutoModRules() {
+	bb.autoMod.mu.Lock()
+	defer bb.autoMod.mu.Unlock()
+
+	bb.autoMod.rules = nil
+}
+
+// check returns the action to take on m, and false if it doesn't break any rule
+func (a *autoMod) check(m *Message, now time.Time) (ModAction, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, rule := range a.rules {
+		if rule.pattern.MatchString(m.Text) {
+			return a.escalate(m.Channel, m.User, rule.action, now), true
+		}
+	}
+	return 0, false
+}
+
+// escalate counts an offense by user in channel, making action harsher for repeat offenders. a.mu
+// must be held.
+func (a *autoMod) escalate(channel, user string, action ModAction, now time.Time) ModAction {
+	if a.offenses == nil {
+		a.offenses = make(map[string]*offense)
+	}
+	for key, o := range a.offenses {
+		if now.Sub(o.last) >= offenseMemory {
+			delete(a.offenses, key)
+		}
+	}
+
+	key := channel + " " + user
+	o := a.offenses[key]
+	if o == nil {
+		o = &offense{}
+		a.offenses[key] = o
+	}
+	o.count++
+	o.last = now
+
+	if action >= ModTimeout {
+		return action
+	}
+	action += ModAction(o.count - 1)
+	if action > ModTimeout {
+		action = ModTimeout
+	}
+	return action
+}
+
+// autoModerate takes action on m if it breaks an auto-moderation rule or floods chat, reporting
+// whether it did
+func (bb *BasicBot) autoModerate(m *Message) bool {
+	if m.Permission() >= PermModerator {
+		return false
+	}
+	now := time.Now()
+	if action, ok := bb.autoMod.check(m, now); ok {
+		bb.moderateLater(m, action, "message not allowed")
+		return true
+	}
+	if p := bb.FloodProtection; p != nil && bb.flood.check(p, m, now) {
+		bb.autoMod.mu.Lock()
+		action := bb.autoMod.escalate(m.Channel, m.User, p.Action, now)
+		bb.autoMod.mu.Unlock()
+		bb.moderateLater(m, action, "flooding chat")
+		return true
+	}
+	return false
+}
+
+// moderateLater takes action on m off the goroutine reading chat, which the Helix requests would
+// otherwise hold up for seconds at a time. With CommandWorkers the user's worker runs it, keeping
+// the actions against each user in order, unless it's too far behind.
+func (bb *BasicBot) moderateLater(m *Message, action ModAction, reason string) {
+	job := func() { bb.moderateMessage(m, action, reason) }
+	if bb.pool == nil || !bb.pool.submit(m.User, job) {
+		go job()
+	}
+}
+
+// moderateMessage takes action on m, logging any failure
+func (bb *BasicBot) moderateMessage(m *Message, action ModAction, reason string) {
+	bb.logger().Infof("#%s %s: %s for %s", m.Channel, action, m.User, reason)
+
+	var err error
+	switch action {
+	case ModWarn:
+		err = bb.Reply(m.Channel, m.ID, fmt.Sprintf("@%s %s, please stop", m.User, reason))
+	case ModDelete:
+		err = bb.Delete(m.Channel, m.ID)
+	case ModTimeout:
+		err = bb.Timeout(m.Channel, m.User, int(bb.autoModTimeout()/time.Second), reason)
+	case ModBan:
+		err = bb.Ban(m.Channel, m.User, reason)
+	}
+	if err != nil {
+		bb.logger().Errorf("auto-moderation of %s: %s", m.User, err)
+	}
+}
+
+func (bb *BasicBot) autoModTimeout() time.Duration {
+	if bb.AutoModTimeout > 0 {
+		return bb.AutoModTimeout
+	}
+	return DefaultAutoModTimeout
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoModLinks(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want bool
+	}{
+		{"check out https://example.com/free", true},
+		{"go to www.example.org", true},
+		{"bit.ly/abc123", true},
+		{"free followers at cheapviews.ru", true},
+		{"great stream... see you tomorrow", false},
+		{"gg wp", false},
+	} {
+		if got := LinkPattern.MatchString(tt.text); got != tt.want {
+			t.Errorf("LinkPattern.MatchString(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAutoModerate(t *testing.T) {
+	conn := newFakeConn()
+	srv := newModerationServer(t)
+	b := &BasicBot{Name: "bot", Moderator: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+	b.AddAutoModRule(regexp.MustCompile(`(?i)\bbadword\b`), ModWarn)
+	b.AddAutoModRule(LinkPattern, ModBan)
+
+	ran := false
+	b.RegisterCommand("hi", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		ran = true
+		return nil
+	})
+
+	for i, line := range []string{
+		"@badges=moderator/1;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #channel :badword is fine from a mod",
+		"@id=1 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!hi badword",
+		"@id=2 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :badword again",
+		"@id=3 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :BADWORD",
+		"@id=4 :spammer!spammer@spammer.tmi.twitch.tv PRIVMSG #channel :buy at https://example.com",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatPrivMsg(context.Background(), m, b)
+		if i == 0 && strings.Contains(conn.written(), "mod") {
+			t.Errorf("acted on a moderator's message: %q", conn.written())
+		}
+	}
+
+	conn.waitFor(t, "@reply-parent-msg-id=1 PRIVMSG #channel :@viewer message not allowed, please stop\r\n")
+	want := []string{
+		`DELETE /moderation/chat?broadcaster_id=id-channel&message_id=2&moderator_id=id-bot`,
+		`POST /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot {"data":{"user_id":"id-spammer","reason":"message not allowed"}}`,
+		`POST /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot {"data":{"user_id":"id-viewer","duration":600,"reason":"message not allowed"}}`,
+	}
+	if got := srv.waitMade(t, len(want)); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if ran {
+		t.Error("ran the command of a message that broke a rule")
+	}
+}
+
+func TestAutoModerateInBackground(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"data":[{"id":"1","login":"channel"},{"id":"2","login":"bot"},{"id":"3","login":"spammer"}]}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	b := &BasicBot{Name: "bot", Moderator: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	b.AddAutoModRule(LinkPattern, ModBan)
+
+	// a slow Helix mustn't hold up reading chat
+	done := make(chan struct{})
+	go func() {
+		handleChatPrivMsg(context.Background(), &Message{Channel: "channel", User: "spammer", Text: "https://example.com"}, b)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handleChatPrivMsg waited for the ban")
+	}
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultReconnectBase is the delay before the first reconnect attempt
+	DefaultReconnectBase = 1 * time.Second
+	// DefaultReconnectMax caps the delay between reconnect attempts
+	DefaultReconnectMax = 2 * time.Minute
+
+	// a connection that stays up for this long resets the backoff to its base delay
+	backoffResetThreshold = 1 * time.Minute
+)
+
+// backoff computes exponentially growing delays with jitter for consecutive failures
+type backoff struct {
+	base     time.Duration
+	max      time.Duration
+	attempts int
+}
+
+func newBackoff(base, max time.Duration) *backoff {
+	if base <= 0 {
+		base = DefaultReconnectBase
+	}
+	if max <= 0 {
+		max = DefaultReconnectMax
+	}
+	if max < base {
+		max = base
+	}
+	return &backoff{base: base, max: max}
+}
+
+// next returns the delay to wait before the next attempt and records the failure.
+//
+// The delay doubles on every consecutive failure up to max, and is randomised between half and
+// all of that value so that many bots don't reconnect in lockstep.
+func (b *backoff) next() time.Duration {
+	d := b.max
+	// guards against overflowing the shift once the cap has long been reached
+	if b.attempts < 32 {
+		if exp := b.base << uint(b.attempts); exp > 0 && exp < b.max {
+			d = exp
+		}
+	}
+	b.attempts++
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// reset returns the backoff to its base delay
+func (b *backoff) reset() {
+	b.attempts = 0
+}
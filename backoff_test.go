@@ -0,0 +1,23 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(time.Second, 8*time.Second)
+
+	for i, want := range []time.Duration{1, 2, 4, 8, 8, 8} {
+		want *= time.Second
+		d := b.next()
+		if d < want/2 || d > want {
+			t.Errorf("attempt %d: delay %s outside [%s, %s]", i, d, want/2, want)
+		}
+	}
+
+	b.reset()
+	if d := b.next(); d > time.Second {
+		t.Errorf("delay after reset = %s, want at most 1s", d)
+	}
+}
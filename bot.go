@@ -2,63 +2,329 @@ package bot
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net"
+	"net/http"
 	"net/textproto"
+	"os"
 	"regexp"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// Regex for parsing PRIVMSG strings.
+// Regex for parsing user commands, from already parsed PRIVMSG strings with the command prefix
+// removed.
 //
-// First matched group is the user's name and the second matched group is the content of the
-// user's message.
-var msgRegex *regexp.Regexp = regexp.MustCompile(`^:(\w+)!\w+@\w+\.tmi\.twitch\.tv (PRIVMSG) #\w+(?: :(.*))?$`)
+// First matched group is the command name and the second matched group is everything after it,
+// which holds the arguments for the command. Names may use letters and digits of any script, and
+// any Unicode space separates them from the arguments.
+var cmdRegex *regexp.Regexp = regexp.MustCompile(`^([\p{L}\p{M}\p{N}_]+)(?:[\s\p{Z}]+(.*))?`)
 
-// Regex for parsing user commands, from already parsed PRIVMSG strings.
-//
-// First matched group is the command name and the second matched group is the argument for the
-// command.
-var cmdRegex *regexp.Regexp = regexp.MustCompile(`^!(\w+)\s?(\w+)?`)
+// TimestampFormat is the format of the timestamps the bot logs
+const TimestampFormat = "2 Jan 15:04:05"
 
-// used for checking a message and seeing if first value is cheer value
-var cheerCheck = make([]string, 1)
+// PSTFormat is the old name of TimestampFormat. Despite the name, timestamps are in local time
+// unless BasicBot.TimeZone is set.
+//
+// Deprecated: use TimestampFormat.
+const PSTFormat = TimestampFormat
 
-// PSTFormat is the format of dates
-const PSTFormat = "2 Jan 15:04:05"
+const (
+	// DefaultReadTimeout is slightly longer than the interval Twitch sends PINGs at
+	DefaultReadTimeout = 6 * time.Minute
+	// DefaultWriteTimeout is how long a write may block by default
+	DefaultWriteTimeout = 10 * time.Second
+	// DefaultConnectTimeout is how long connecting to the server may take by default
+	DefaultConnectTimeout = 10 * time.Second
+	// DefaultCommandTimeout is how long command handlers should take by default
+	DefaultCommandTimeout = 10 * time.Second
+	// DefaultReadBufferSize fits the longest lines Twitch sends, whose tags can list hundreds of
+	// emote positions, without growing the buffer
+	DefaultReadBufferSize = 16 * 1024
+)
 
 // BasicBot struct
 type BasicBot struct {
+	// Channel is the channel to join. Use Channels to join more than one.
 	Channel string
-	conn    net.Conn
-	// ws          *websocket.Conn
+	// Channels are joined alongside Channel over the same connection
+	Channels []string
+	conn     ircConn
+	reader   *textproto.Reader
+
+	// outgoing queues lines for the writer goroutine, which is the only one writing to conn
+	connMu     sync.Mutex
+	outgoing   chan outbound
+	stopWriter chan struct{}
+	writerDone chan struct{}
+	held       heldLines
+	// chatStop is closed when handleChat stops reading the current connection, nil outside it
+	chatStop <-chan struct{}
+	// closed is set once Disconnect has closed conn
+	closed bool
+	state  int32 // ConnState
+	// liveFor is how long the last connection stayed up, set by Disconnect
+	liveFor time.Duration
+	// shutdown cancels the context StartContext is running with, nil when it isn't running
+	shutdown context.CancelFunc
+
 	Credentials *OAuthCred
-	MsgRate     time.Duration
-	Name        string
-	Port        string
-	PrivatePath string
-	Server      string
-	startTime   time.Time
-}
+	// credMu guards Credentials while the bot runs, and refresh is the refresh in progress
+	credMu  sync.Mutex
+	refresh *credRefresh
+	// MsgRate is the minimum time between chat messages sent by the bot
+	MsgRate time.Duration
+	// RateLimitCooldown is how long the bot stops sending chat messages when Twitch says it's
+	// over its message limit. Defaults to DefaultRateLimitCooldown.
+	RateLimitCooldown time.Duration
+	Name              string
+	Port              string
+	PrivatePath       string
+	Server            string
+	startTime         time.Time
 
-// Ping is the struct for maintaining connection to WSS server
-type Ping struct {
-	Type string `json:"type"`
-}
+	// DryRun logs the messages Say, Reply and Whisper would send instead of sending them, to try
+	// out commands in a live channel. The connection is kept alive as usual.
+	DryRun bool
+	// Anonymous connects without credentials to read chat. Anonymous bots can't send messages,
+	// and Name is replaced with a random justinfan nick as Twitch requires.
+	Anonymous bool
+	// CredentialSource is where ReadCredentials loads Credentials from. Defaults to the JSON file
+	// at PrivatePath.
+	CredentialSource CredentialSource
+	// TokenURL is where expired tokens are refreshed. Defaults to OAuthTokenURL.
+	TokenURL string
+	// HTTPClient is used for requests to Twitch's APIs. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Store saves state such as text commands and counters so it survives restarts. Optional.
+	Store  StateStore
+	saveMu sync.Mutex
+
+	// HelixURL is the base URL of the Helix API used by Helix. Defaults to HelixURL.
+	HelixURL string
+	// ids caches the user ids of the bot and the channels it has moderated, by login
+	idMu sync.Mutex
+	ids  map[string]string
+
+	// UseTLS connects to the server over TLS, which Twitch offers on port 6697
+	UseTLS bool
+	// TLSConfig optionally customises the TLS connection. ServerName defaults to Server.
+	TLSConfig *tls.Config
+	// Dialer makes the connection to the server, for example through a proxy. Defaults to a
+	// net.Dialer.
+	Dialer Dialer
+	// ConnectTimeout bounds how long the default Dialer and the TLS handshake can take. Defaults
+	// to DefaultConnectTimeout.
+	ConnectTimeout time.Duration
+	// ReadBufferSize is the size of the buffer lines are read from the connection with. Defaults
+	// to DefaultReadBufferSize.
+	ReadBufferSize int
+
+	// ReconnectBase is the delay before the first reconnect attempt, doubling on each consecutive
+	// failure. Defaults to DefaultReconnectBase.
+	ReconnectBase time.Duration
+	// ReconnectMax caps the delay between reconnect attempts. Defaults to DefaultReconnectMax.
+	ReconnectMax time.Duration
+	// ReconnectMaxAttempts is the number of consecutive failed attempts after which Start gives
+	// up. Zero means retry forever.
+	ReconnectMaxAttempts int
+	// ShouldReconnect decides whether Start reconnects after err, which ended the connection or
+	// kept it from being made. Start returns err when it doesn't. Defaults to
+	// DefaultShouldReconnect.
+	ShouldReconnect func(err error) bool
+
+	// Capabilities are the Twitch IRC capabilities requested when joining. Defaults to
+	// DefaultCapabilities when nil; set it to an empty slice to request none.
+	Capabilities []string
+
+	// MessageLimit is the number of messages Say may send per 30 seconds. Defaults to
+	// DefaultMessageLimit.
+	MessageLimit int
+	// ModMessageLimit replaces MessageLimit when Moderator is set. Defaults to
+	// DefaultModMessageLimit.
+	ModMessageLimit int
+	// Moderator should be set when the bot is a moderator or VIP in the channel, which raises
+	// Twitch's limit on how many messages it may send. It's detected from USERSTATE when the
+	// twitch.tv/tags capability is requested, so only needs setting without it.
+	Moderator bool
+	limiter   rateLimiter
+
+	// Follower should be set when the bot's account follows the channels it joins, so it can talk
+	// in followers-only mode
+	Follower bool
+	// WaitForRoomModes makes messages wait to be sent while the channel's chat modes don't allow
+	// the bot to talk, rather than Say and Reply returning ErrRoomRestricted. They're dropped if
+	// the modes don't change within 10 minutes. Slow mode is always waited out, without Say
+	// blocking.
+	WaitForRoomModes bool
+	// SplitLongMessages makes Say and Reply send messages longer than MaxMessageLength as several
+	// messages, split between words, rather than return ErrMessageTooLong
+	SplitLongMessages bool
+
+	whisperSecond rateLimiter
+	whisperMinute rateLimiter
+
+	// HistorySize is how many chat messages RecentMessages can return. Defaults to
+	// DefaultHistorySize; negative keeps none.
+	HistorySize int
+	// HistoryExcludeOwn leaves messages sent by the bot's account out of the history
+	HistoryExcludeOwn bool
+	// HistoryExcludeCommands leaves !commands out of the history
+	HistoryExcludeCommands bool
+	history                history
+
+	subscribers subscribers
+	roomStates  roomStates
+	counters    counters
+	userStates  userStates
+
+	// AutoModTimeout is how long auto-moderation times users out for. Defaults to
+	// DefaultAutoModTimeout.
+	AutoModTimeout time.Duration
+	autoMod        autoMod
+
+	// FloodProtection takes action on users sending too many messages, or the same one over and
+	// over, for example &DefaultFloodProtection. Moderators and the broadcaster are exempt.
+	FloodProtection *FloodProtection
+	flood           floodDetector
+
+	// SongRequestBits is how many bits a cheer needs to request a song, once EnableSongRequests
+	// has been called. Zero leaves requests to the !sr command.
+	SongRequestBits int
+	songRequests    bool
+	announcements   announcements
+	shoutouts       shoutouts
+	songs           SongQueue
+
+	// OnRawLine is called with every line read from the server, exactly as received and before
+	// it's parsed, including PINGs and lines that fail to parse. It's called on the goroutine
+	// reading chat, so lines arrive in order, and must return quickly.
+	OnRawLine func(line string)
+	// ReplayDelay is how long ReplayReader waits between lines. Zero replays them as fast as
+	// they're handled.
+	ReplayDelay time.Duration
+	// OnCheer is called for every message that cheers bits, with the total number of bits
+	OnCheer func(user string, bits int, message string)
+	// OnFirstMessage is called for a user's first message ever in the channel, e.g. to welcome
+	// them. It needs the twitch.tv/tags capability.
+	OnFirstMessage func(m *Message)
+	// OnUnknownCommand is called for commands nobody registered, whoever sent them, e.g. to point
+	// users to !help. bb is the bot that would have run the command, see Dispatcher.Responder.
+	OnUnknownCommand func(bb *BasicBot, msg *Message, cmd *Command)
+	// OnUserJoin and OnUserLeave are called as users enter and leave chat. They need the
+	// twitch.tv/membership capability, and are late and incomplete, see Chatters.
+	OnUserJoin  func(user, channel string)
+	OnUserLeave func(user, channel string)
+	// TrackChatters keeps a list of the users in each channel for Chatters and Lurkers
+	TrackChatters bool
+	chatters      chatters
+
+	// PointsInterval is how often points are awarded once EnableLoyalty has been called.
+	// Defaults to DefaultPointsInterval.
+	PointsInterval time.Duration
+	// PointsPerInterval is how many points users present get each PointsInterval. Defaults to
+	// DefaultPointsPerInterval.
+	PointsPerInterval int
+	// PointsChatBonus is how many more points users get for chatting during the interval
+	PointsChatBonus int
+	loyalty         loyalty
+
+	// RaffleSubscriberWeight, when above one, is how many times as likely subscribers are to win
+	// raffles they enter with !enter
+	RaffleSubscriberWeight int
+	raffles                raffles
+
+	// OnSubscription is called for every subscription, resub and gifted subscription
+	OnSubscription func(ev *SubEvent)
+	// OnRaid is called when another broadcaster raids the channel
+	OnRaid func(ev *RaidEvent)
+
+	// OnTimeout is called when a user is timed out
+	OnTimeout func(ev *ModerationEvent)
+	// OnBan is called when a user is permanently banned
+	OnBan func(ev *ModerationEvent)
+	// OnChatClear is called when a moderator clears the whole chat
+	OnChatClear func(ev *ModerationEvent)
+	// OnMessageDeleted is called when a single message is deleted
+	OnMessageDeleted func(ev *ModerationEvent)
+	// OnNotice is called for the NOTICEs Twitch sends, like the reasons messages from the bot were
+	// dropped
+	OnNotice func(n *Notice)
+
+	// OnConnect is called once the bot has connected and joined its channels, again after every
+	// reconnect. It runs in its own goroutine.
+	OnConnect func()
+	// OnDisconnect is called when the connection is lost, with the error that caused it, or nil
+	// when the bot was shut down. It runs in its own goroutine.
+	OnDisconnect func(err error)
+	// OnPanic is called when a command handler panics, with the command's name and the value it
+	// panicked with. The panic is logged and the bot carries on either way. It runs in its own
+	// goroutine.
+	OnPanic func(command string, recovered interface{})
+	// CommandTimeout is how long a command handler should take. The context handlers are given
+	// is cancelled after it, and handlers that overrun it are logged. Defaults to
+	// DefaultCommandTimeout.
+	CommandTimeout time.Duration
+	// CommandWorkers is how many commands may run at once, on goroutines of their own, so slow
+	// handlers don't hold up reading chat. Each user's commands still run in the order they were
+	// sent, but commands from different users may finish in any order, and commands arriving
+	// while too many are waiting are dropped. Zero runs commands one at a time as they're read.
+	CommandWorkers int
+	pool           *commandPool
+
+	// OnWhisper is called for every whisper sent to the bot. The sender is msg.User.
+	OnWhisper func(msg *Message)
+
+	// EventSub receives events such as follows and channel point redemptions, which aren't sent
+	// over IRC. It's optional and runs alongside the chat connection.
+	EventSub *EventSubClient
 
-// OAuthCred struct
-type OAuthCred struct {
-	Password string `json:"password,omitempty"`
+	// ReadTimeout is how long the bot waits to receive anything before treating the connection
+	// as dead and reconnecting. Defaults to DefaultReadTimeout.
+	ReadTimeout time.Duration
+	// WriteTimeout is how long a single write may block before it fails. Defaults to
+	// DefaultWriteTimeout.
+	WriteTimeout time.Duration
+
+	// PingInterval is how often the bot PINGs the server to check the connection is alive when
+	// nothing else has been received. Defaults to DefaultPingInterval; negative disables it.
+	PingInterval time.Duration
+	// PongTimeout is how long to wait for the server to answer a PING before reconnecting.
+	// Defaults to DefaultPongTimeout.
+	PongTimeout time.Duration
+	lastRecv    int64 // unix nanoseconds
+	lastPongAt  int64 // unix nanoseconds
+
+	// Logger receives all of the bot's output. Defaults to a StdLogger writing to stdout.
+	Logger Logger
+	// TimeZone is the time zone of TimeStamp, and of the timestamps logged when Logger isn't set.
+	// Defaults to the machine's local time.
+	TimeZone *time.Location
+	// Metrics receives counts of messages, commands, reconnects and errors. Optional.
+	Metrics Metrics
+
+	// CommandPrefix is what chat messages start with to run a command. Defaults to
+	// DefaultCommandPrefix, "!".
+	CommandPrefix string
+	// Dispatcher holds the bot's commands and their cooldowns. Set it to share them with other
+	// bots; see Dispatcher. Created when the first command is registered otherwise.
+	Dispatcher *Dispatcher
+	dispatchMu sync.Mutex
+
+	chanMu sync.Mutex
+	joined []string // channels to be in, rejoined on reconnect; nil until first joined
 }
 
 // TwitchBot interface
 type TwitchBot interface {
-	Connect()
+	Connect() error
 	Disconnect()
 	HandleChat() error
 	JoinChannel()
@@ -70,220 +336,689 @@ type TwitchBot interface {
 // Start starts a loop where the bot will attempt to connect to the Twitch channel
 // it will continue to do so until told to shut down
 func (bb *BasicBot) Start() {
+	bb.StartContext(context.Background())
+}
+
+// StartContext is like Start, but stops the bot when ctx is cancelled. On cancellation the bot
+// parts the channel, closes the connection and returns ctx.Err(). It returns nil when stopped by
+// Shutdown instead.
+func (bb *BasicBot) StartContext(ctx context.Context) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	bb.connMu.Lock()
+	bb.shutdown = cancel
+	bb.connMu.Unlock()
+	defer func() {
+		bb.connMu.Lock()
+		bb.shutdown = nil
+		bb.connMu.Unlock()
+	}()
+
 	err := bb.ReadCredentials()
 	if err != nil {
-		fmt.Println(err)
-		fmt.Println("Aborting...")
-		return
+		bb.logger().Errorf("%s. Aborting...", err)
+		return err
 	}
+	if err = bb.loadState(); err != nil {
+		bb.logger().Errorf("%s. Aborting...", err)
+		return err
+	}
+
+	// EventSub runs over its own connection, which reconnects independently of the chat one
+	bb.handleEvents(ctx)
 
+	retry := newBackoff(bb.ReconnectBase, bb.ReconnectMax)
 	for {
-		bb.Connect()
-		bb.JoinChannel()
-		bb.HandleEvents()
-		err = bb.HandleChat()
-		if err != nil {
-			// attempts to reconnect upon chat error
-			time.Sleep(1 * time.Second)
-			fmt.Println(err)
-			fmt.Println("Starting bot again...")
-		} else {
-			return
+		if ctx.Err() != nil {
+			return parent.Err()
+		}
+
+		if err = bb.Connect(); err == nil {
+			bb.JoinChannel()
+			if bb.OnConnect != nil {
+				bb.runCallback("OnConnect", bb.OnConnect)
+			}
+			err = bb.handleChat(ctx)
+			if bb.OnDisconnect != nil {
+				cause := err
+				if ctx.Err() != nil {
+					cause = nil
+				}
+				bb.runCallback("OnDisconnect", func() { bb.OnDisconnect(cause) })
+			}
+			if err == nil {
+				return nil
+			}
+			if ctx.Err() != nil {
+				bb.logger().Infof("Shutting down...")
+				bb.Disconnect()
+				bb.saveState()
+				return parent.Err()
+			}
+			// a connection that stayed up for a while isn't a consecutive failure
+			if bb.Uptime() >= backoffResetThreshold {
+				retry.reset()
+			}
+		}
+
+		if !bb.shouldReconnect(err) {
+			bb.logger().Errorf("%s. Aborting...", err)
+			return err
 		}
+
+		if bb.ReconnectMaxAttempts > 0 && retry.attempts >= bb.ReconnectMaxAttempts {
+			bb.logger().Errorf("%s. Giving up after %d attempts. Aborting...", err, retry.attempts)
+			return err
+		}
+
+		// attempts to reconnect upon connection or chat error
+		delay := retry.next()
+		bb.logger().Errorf("%s", err)
+		bb.logger().Infof("Starting bot again in %s...", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return parent.Err()
+		}
+		bb.metrics().Inc(MetricReconnects)
+	}
+}
+
+// DefaultShouldReconnect is the ShouldReconnect used by default. It reconnects after any error
+// except ErrAuthFailed, as retrying with the same credentials would only fail again, and the
+// context being cancelled. Timeouts are retried, as network errors report those too.
+func DefaultShouldReconnect(err error) bool {
+	return !errors.Is(err, ErrAuthFailed) && !errors.Is(err, context.Canceled)
+}
+
+func (bb *BasicBot) shouldReconnect(err error) bool {
+	if bb.ShouldReconnect == nil {
+		return DefaultShouldReconnect(err)
 	}
+	return bb.ShouldReconnect(err)
+}
+
+// runCallback runs one of the lifecycle callbacks in its own goroutine, so it can't hold up the
+// connection, logging it if it panics
+func (bb *BasicBot) runCallback(name string, callback func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				bb.logger().Errorf("%s panicked: %v", name, r)
+			}
+		}()
+		callback()
+	}()
+}
+
+// runHandler runs a command's handler with a context cancelled after CommandTimeout, recovering
+// if it panics so a broken command can't take down the bot. A panic is logged with its stack, and
+// isn't returned as an error.
+func (bb *BasicBot) runHandler(ctx context.Context, handler CommandHandler, m *Message, cmd *Command) error {
+	timeout := bb.commandTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			bb.logger().Errorf("!%s took %s, over the %s CommandTimeout", cmd.Name, time.Since(start).Round(time.Millisecond), timeout)
+		}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			bb.logger().Errorf("!%s panicked: %v\n%s", cmd.Name, r, debug.Stack())
+			bb.metrics().Inc(MetricErrors)
+			if bb.OnPanic != nil {
+				bb.runCallback("OnPanic", func() { bb.OnPanic(cmd.Name, r) })
+			}
+		}
+	}()
+	return handler(ctx, bb, m, cmd)
+}
+
+func (bb *BasicBot) commandTimeout() time.Duration {
+	if bb.CommandTimeout <= 0 {
+		return DefaultCommandTimeout
+	}
+	return bb.CommandTimeout
 }
 
 // Connect method for connecting to the twitch channel
-func (bb *BasicBot) Connect() {
-	var err error
-	fmt.Printf("[%s] Connecting to %s...\n", timeStamp(), bb.Server)
+func (bb *BasicBot) Connect() error {
+	bb.logger().Infof("Connecting to %s...", bb.server())
+	bb.setState(StateConnecting)
 
 	// makes connection to Twitch IRC server
-	bb.conn, err = net.Dial("tcp", bb.Server+":"+bb.Port)
+	conn, err := bb.dialer().Dial("tcp", net.JoinHostPort(bb.server(), bb.port()))
 	if err != nil {
-		fmt.Printf("[%s] cannot connect to %s, retrying.\n", timeStamp(), bb.Server)
-		return
+		bb.setState(StateDisconnected)
+		bb.metrics().Inc(MetricErrors)
+		return fmt.Errorf("BasicBot.Connect: %w: cannot connect to %s: %w", ErrNotConnected, bb.server(), err)
 	}
-	// https://37.14.165.59
-	// bb.ws, err = websocket.Dial("wss://pubsub-edge.twitch.tv", "", "https://")
-	// fmt.Println("=========================>", bb.ws)
-	go maintainWsConn()
-
-	if err != nil {
-		fmt.Printf("[%s] cannot connect to %s, retrying.\n", timeStamp(), bb.Server)
-		return
+	if bb.UseTLS {
+		tlsConn := tls.Client(conn, bb.tlsConfig())
+		tlsConn.SetDeadline(time.Now().Add(bb.connectTimeout()))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			bb.setState(StateDisconnected)
+			bb.metrics().Inc(MetricErrors)
+			return fmt.Errorf("BasicBot.Connect: %w: TLS handshake with %s: %w", ErrNotConnected, bb.server(), err)
+		}
+		tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
 	}
-	fmt.Printf("[%s] Connected to %s!\n", timeStamp(), bb.Server)
-	fmt.Println("HERE !!!!!!!!!!!!!!")
-	bb.startTime = time.Now()
+	bb.setConn(conn)
+	bb.reader = bb.newReader(conn)
+	bb.metrics().Inc(MetricConnects)
+
+	bb.logger().Infof("Connected to %s!", bb.server())
+	return nil
 }
 
-var err error
+// newReader returns a reader for the lines received on conn
+func (bb *BasicBot) newReader(conn io.Reader) *textproto.Reader {
+	size := bb.ReadBufferSize
+	if size <= 0 {
+		size = DefaultReadBufferSize
+	}
+	return textproto.NewReader(bufio.NewReaderSize(conn, size))
+}
 
-// HandleEvents listens to events such as subscribers/new or old, as well as bit usage
+// HandleEvents listens to events such as subscribers/new or old, as well as bit usage, by running
+// the EventSub client in the background. It does nothing if EventSub is nil or already running.
 func (bb *BasicBot) HandleEvents() {
+	bb.handleEvents(context.Background())
+}
 
-	// var msg = make([]byte, 512)
+// handleEvents runs the EventSub client in the background until ctx is cancelled
+func (bb *BasicBot) handleEvents(ctx context.Context) {
+	c := bb.EventSub
+	if c == nil || atomic.LoadInt32(&c.running) == 1 {
+		return
+	}
+	if c.botToken == nil {
+		c.botToken = func() string {
+			if cred := bb.credentials(); cred != nil {
+				return cred.Password
+			}
+			return ""
+		}
+	}
+	if c.Logger == nil {
+		c.Logger = bb.logger()
+	}
 
 	go func() {
-		for {
-			// line, err := bb.ws.Read(msg)
-			// fmt.Println("in Handle Events", line)
-			if err != nil {
-				bb.Disconnect()
-				fmt.Println("ERROR IN READING LINE FROM SOCKET", err)
-				// return errors.New("bb.Bot.HandleChat: Failed to read from channel. Disconnected")
-			}
-			// fmt.Printf("[%s] %s\n", timeStamp(), line)
-			// fmt.Println("in Handle Events", line)
+		if err := c.Run(ctx); err != nil && ctx.Err() == nil {
+			bb.logger().Errorf("%s", err)
 		}
 	}()
 }
 
 // HandleChat reads the messages of the channel
 func (bb *BasicBot) HandleChat() error {
-	fmt.Printf("[%s] Watching #%s...\n", timeStamp(), bb.Channel)
+	return bb.handleChat(context.Background())
+}
+
+// handleChat reads the messages of the channel until the connection fails or ctx is cancelled.
+// On cancellation the connection is left open and ctx.Err() is returned.
+func (bb *BasicBot) handleChat(ctx context.Context) error {
+	bb.logger().Infof("Watching #%s...", strings.Join(bb.channels(), ", #"))
+
+	// unblocks a pending read as soon as ctx is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			bb.conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+	bb.connMu.Lock()
+	bb.chatStop = stop
+	bb.connMu.Unlock()
+	defer func() {
+		bb.connMu.Lock()
+		bb.chatStop = nil
+		bb.connMu.Unlock()
+	}()
+	go bb.keepalive(stop)
+	bb.chatters.reset()
+	bb.startAnnouncements(stop)
+	bb.startLoyalty(stop)
+	defer bb.stopAnnouncements()
+
+	if bb.CommandWorkers > 0 {
+		bb.pool = newCommandPool(bb.CommandWorkers)
+		defer func() {
+			bb.pool.stop()
+			bb.pool = nil
+		}()
+	}
 
 	// reads from connection
-	tp := textproto.NewReader(bufio.NewReader(bb.conn))
+	tp := bb.reader
+	if tp == nil {
+		tp = bb.newReader(bb.conn)
+	}
 
 	// reads messages
 	for {
+		// Twitch PINGs about every 5 minutes, so silence for longer means the connection is dead.
+		// The deadline is set before checking ctx so it can't undo the cancellation's deadline.
+		bb.conn.SetReadDeadline(time.Now().Add(bb.readTimeout()))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		line, err := tp.ReadLine()
 		if err != nil {
-			bb.Disconnect()
-			return errors.New("bb.Bot.HandleChat: Failed to read from channel. Disconnected")
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				bb.logger().Errorf("nothing received for %s, assuming the connection is dead", bb.readTimeout())
+			}
+			// the connection is likely dead, so there's no parting
+			bb.closeConn()
+			bb.metrics().Inc(MetricErrors)
+			return fmt.Errorf("bb.Bot.HandleChat: %w: failed to read from channel: %w", ErrDisconnected, err)
+		}
+		bb.received()
+		msg := bb.readMessage(line)
+		if msg == nil {
+			continue
 		}
-		fmt.Printf("[%s] %s\n", timeStamp(), line)
 
-		if "PING :tmi.twitch.tv" == line {
+		switch msg.Type {
+		case "PING":
 			// respond to PING message with a PONG message, to maintain the connection
-			bb.conn.Write([]byte("PONG :tmi.twitch.tv\r\n"))
+			bb.send("PONG :" + msg.Text + "\r\n")
+			continue
+		case "PONG":
+			atomic.StoreInt64(&bb.lastPongAt, time.Now().UnixNano())
 			continue
-		} else {
-			matches := msgRegex.FindStringSubmatch(line)
-			if matches != nil {
-				msgType := matches[2]
-
-				switch msgType {
-				case "PRIVMSG":
-					handleChatPrivMsg(matches, bb)
-				default:
-					// see message type
-					// as more msg types come then the more this switch will grow
-					fmt.Println("DEFAULT:", msgType)
+		case "NOTICE":
+			if isAuthFailure(msg) {
+				// Twitch closes the connection anyway
+				bb.closeConn()
+				if !bb.credentials().canRefresh() {
+					return fmt.Errorf("bb.Bot.HandleChat: %w: %s", ErrAuthFailed, msg.Text)
 				}
+				bb.logger().Errorf("%s, refreshing the OAuth token...", msg.Text)
+				if err := bb.RefreshCredentials(ctx); err != nil {
+					return fmt.Errorf("bb.Bot.HandleChat: %w: %s", ErrAuthFailed, err)
+				}
+				// reconnects with the new token
+				return fmt.Errorf("bb.Bot.HandleChat: %w: login authentication failed, token refreshed", ErrDisconnected)
 			}
-
 		}
-		time.Sleep(bb.MsgRate)
+		bb.dispatch(ctx, msg)
+	}
 
+}
+
+// readMessage parses a line read from the server, returning nil if it can't be parsed
+func (bb *BasicBot) readMessage(line string) *Message {
+	if bb.OnRawLine != nil {
+		bb.OnRawLine(line)
 	}
+	bb.metrics().Inc(MetricMessagesReceived)
+	bb.logger().Debugf("%s", line)
 
+	msg, err := ParseMessage(line)
+	if err != nil {
+		bb.logger().Debugf("%s", err)
+		bb.metrics().Inc(MetricErrors)
+		return nil
+	}
+	bb.subscribers.publish(*msg)
+	return msg
 }
 
-func handleChatPrivMsg(s []string, bb *BasicBot) {
-	userName := s[1]
-	msg := s[3]
-	cheerCheck = strings.Split(msg, " ")
-	// logging the message with timestamp
-	fmt.Printf("[%s] %s: %s\n", timeStamp(), userName, msg)
-	if cheerCheck[0] == "Cheer100" {
-		// This is working and will later be used to process song requests
+// dispatch hands msg to the handler for its type
+func (bb *BasicBot) dispatch(ctx context.Context, msg *Message) {
+	switch msg.Type {
+	case "PRIVMSG":
+		bb.remember(msg)
+		if !strings.EqualFold(msg.User, bb.Name) {
+			bb.announcements.chatted(msg.Channel)
+		}
+		bb.chatters.chatted(msg.Channel, msg.User, bb.TrackChatters, time.Now())
+		bb.loyalty.chatted(msg.User)
+		handleChatPrivMsg(ctx, msg, bb)
+	case "USERNOTICE":
+		handleUserNotice(msg, bb)
+	case "JOIN", "PART", "353":
+		handleMembership(msg, bb)
+	case "CLEARCHAT", "CLEARMSG":
+		handleModeration(msg, bb)
+	case "ROOMSTATE":
+		handleRoomState(msg, bb)
+	case "USERSTATE", "GLOBALUSERSTATE":
+		handleUserState(msg, bb)
+	case "WHISPER":
+		handleWhisper(msg, bb)
+	case "NOTICE":
+		handleNotice(msg, bb)
+	default:
+		// as more msg types come then the more this switch will grow
+		bb.logger().Debugf("unhandled message type: %s", msg.Type)
+	}
+}
+
+func handleChatPrivMsg(ctx context.Context, m *Message, bb *BasicBot) {
+	userName := m.User
+	msg := m.Text
+	// logging the message with the time it was sent
+	if l, ok := bb.logger().(MessageLogger); ok {
+		l.LogMessage(m)
+	} else {
+		bb.logAt(m.SentAt, "#%s %s: %s", m.Channel, userName, msg)
+	}
+	if bb.autoModerate(m) {
+		return
+	}
+	if m.Bits > 0 {
+		bb.logger().Debugf("%s cheered %d bits", userName, m.Bits)
+		if bb.OnCheer != nil {
+			bb.OnCheer(userName, m.Bits, msg)
+		}
+		bb.cheerSongRequest(m)
+	}
+	if m.FirstMessage && bb.OnFirstMessage != nil {
+		bb.OnFirstMessage(m)
 	}
 
 	// parse commands from user message
-	cmdMatches := cmdRegex.FindStringSubmatch(msg)
-	if cmdMatches != nil {
-		cmd := cmdMatches[1]
-		// arg := cmdMatches[2]
-		// fmt.Println("ARG----------------", arg)
-
-		// channel-owener specific commands
-		if userName == bb.Channel {
-			handleOwnerMessages(cmd, bb)
+	if cmd, ok := bb.parseCommand(msg); ok {
+		d := bb.dispatcher()
+		name := d.resolve(cmd.Name)
+		registered, ok := d.lookupCommand(m.Channel, name)
+		if !ok {
+			bb.logger().Debugf("%s command received", cmd.Name)
+			if bb.OnUnknownCommand != nil {
+				responder := d.responder(bb)
+				bb.runCallback("OnUnknownCommand", func() { bb.OnUnknownCommand(responder, m, cmd) })
+			}
+			return
+		}
+		if m.Permission() < registered.permission {
+			bb.logger().Debugf("!%s from %s ignored, restricted to %s", cmd.Name, userName, registered.permission)
+			return
+		}
+		if !d.cooldowns.use(m.Channel, name, userName, time.Now()) {
+			bb.logger().Debugf("!%s from %s ignored, on cooldown", cmd.Name, userName)
+			return
+		}
+		responder := d.responder(bb)
+		if bb.pool == nil {
+			responder.runCommand(ctx, registered.handler, m, cmd)
+			return
+		}
+		if !bb.pool.submit(userName, func() { responder.runCommand(ctx, registered.handler, m, cmd) }) {
+			bb.logger().Errorf("!%s from %s dropped, too many commands waiting", cmd.Name, userName)
+			bb.metrics().Inc(MetricErrors)
 		}
 	}
 }
 
-func handleOwnerMessages(cmd string, bb *BasicBot) {
-	switch cmd {
-	case "tbdown":
-		fmt.Printf(
-			"[%s] Shutdown command received. Shutting down now...\n",
-			timeStamp(),
-		)
-		bb.Disconnect()
-		return
+// runCommand runs a command's handler, logging and measuring it
+func (bb *BasicBot) runCommand(ctx context.Context, handler CommandHandler, m *Message, cmd *Command) {
+	start := time.Now()
+	err := bb.runHandler(ctx, handler, m, cmd)
+	bb.metrics().Observe(MetricCommandDuration, time.Since(start))
+	bb.metrics().Inc(MetricCommandsHandled)
+	if err != nil {
+		bb.logger().Errorf("!%s: %s", cmd.Name, err)
+		bb.metrics().Inc(MetricErrors)
+	}
+}
 
-	case "repeat":
-		bb.Say(cmd)
-	default:
-		fmt.Printf("%s command received", cmd)
+// Say speaks to the channel, which must be one of the channels the bot has joined.
+//
+// Say blocks while the bot is over its message limit, see QueueDepth. Otherwise the message is
+// queued to be written in order by the connection's writer and Say returns straight away.
+func (bb *BasicBot) Say(channel, msg string) error {
+	if err := bb.say("", channel, msg); err != nil {
+		return fmt.Errorf("BasicBot.Say: %w", err)
+	}
+	return nil
+}
+
+// Reply answers the message with the id parentID in channel, threading msg under it. Handlers
+// find the id in Message.ID. Reply is the same as Say when parentID is empty.
+func (bb *BasicBot) Reply(channel, parentID, msg string) error {
+	tags := ""
+	if parentID != "" {
+		if strings.ContainsAny(parentID, " ;") {
+			return fmt.Errorf("BasicBot.Reply: invalid message id %q", parentID)
+		}
+		tags = "@reply-parent-msg-id=" + parentID + " "
 	}
+	if err := bb.say(tags, channel, msg); err != nil {
+		return fmt.Errorf("BasicBot.Reply: %w", err)
+	}
+	return nil
 }
 
-// Say speaks to the channel
-func (bb *BasicBot) Say(msg string) error {
+// say sends msg to channel as a PRIVMSG, prefixed with tags
+func (bb *BasicBot) say(tags, channel, msg string) error {
 	if msg == "" {
-		return errors.New("BasicBot.Say: msg was empty")
+		return ErrEmptyMessage
+	}
+	if bb.Anonymous {
+		return ErrAnonymous
 	}
-	_, err := bb.conn.Write([]byte(fmt.Sprintf("PRIVMSG #%s %s\r\n", bb.Channel, msg)))
+	// user input echoed back mustn't be able to end the line and add commands of its own
+	for _, s := range []string{tags, channel, msg} {
+		if err := checkLine(s); err != nil {
+			return err
+		}
+	}
+	channel = NormalizeChannel(channel)
+	parts, err := bb.splitMessage(msg)
 	if err != nil {
 		return err
 	}
+	if bb.DryRun {
+		for _, part := range parts {
+			bb.logger().Infof("dry run, not sending to #%s: %s%s", channel, tags, part)
+		}
+		return nil
+	}
+	if !bb.connected() {
+		return fmt.Errorf("cannot send to #%s: %w", channel, ErrNotConnected)
+	}
+	for _, part := range parts {
+		if err := bb.checkRoom(channel); err != nil {
+			return err
+		}
+		bb.limiter.wait(bb.messageLimit(channel), rateLimitWindow)
+		if err := bb.send(fmt.Sprintf("%sPRIVMSG %s :%s\r\n", tags, IRCChannel(channel), part)); err != nil {
+			return fmt.Errorf("cannot send to #%s: %w", channel, err)
+		}
+		bb.metrics().Inc(MetricMessagesSent)
+	}
 	return nil
 }
 
-// JoinChannel joins the requested channel
+// JoinChannel joins the requested channels
 func (bb *BasicBot) JoinChannel() {
-	fmt.Printf("[%s] Joining #%s...\n", timeStamp(), bb.Channel)
-	bb.conn.Write([]byte("PASS " + bb.Credentials.Password + "\r\n"))
-	bb.conn.Write([]byte("NICK " + bb.Name + "\r\n"))
-	bb.conn.Write([]byte("JOIN #" + bb.Channel + "\r\n"))
+	channels := bb.rememberChannels()
+	bb.logger().Infof("Joining #%s...", strings.Join(channels, ", #"))
+	if err := bb.requestCapabilities(); err != nil {
+		bb.logger().Errorf("%s", err)
+	}
+	if !bb.Anonymous {
+		bb.send("PASS " + bb.credentials().Password + "\r\n")
+	}
+	// Twitch logins are lowercase, Name may be written as it's displayed
+	bb.send("NICK " + strings.ToLower(bb.Name) + "\r\n")
+	for _, channel := range channels {
+		bb.send("JOIN " + IRCChannel(channel) + "\r\n")
+	}
 
-	fmt.Printf("[%s] Joined #%s as @%s!\n", timeStamp(), bb.Channel, bb.Name)
+	bb.setState(StateConnected)
+	bb.logger().Infof("Joined #%s as @%s!", strings.Join(channels, ", #"), bb.Name)
 }
 
-// ReadCredentials reads the credentials from a path in order to make a connection
-func (bb *BasicBot) ReadCredentials() error {
-	// reads from the file
-	credFile, err := ioutil.ReadFile(bb.PrivatePath)
-	if err != nil {
-		return err
+// Disconnect will disconnect from the twitch channel connected. When the channels have been
+// joined, it parts them first, waiting up to partFlushTimeout for the PART lines to be written.
+//
+// It is safe to call Disconnect more than once, or before Connect: only the first call after a
+// connection is made closes it.
+func (bb *BasicBot) Disconnect() {
+	if bb.IsConnected() {
+		bb.partAll()
 	}
+	bb.closeConn()
+}
 
-	bb.Credentials = &OAuthCred{}
-	// parses the file contents
-	dec := json.NewDecoder(strings.NewReader(string(credFile)))
-	if err = dec.Decode(bb.Credentials); err != nil && io.EOF != err {
-		return err
+// partAll sends PART for every channel, waiting for the lines to be written or partFlushTimeout
+func (bb *BasicBot) partAll() {
+	channels := bb.channels()
+	if len(channels) == 0 {
+		return
+	}
+	done := make(chan error, 1)
+	var writerDone <-chan struct{}
+	for i, channel := range channels {
+		out := outbound{line: "PART " + IRCChannel(channel) + "\r\n"}
+		if i == len(channels)-1 {
+			// lines are written in order, so the last one being written flushes them all
+			out.done = done
+		}
+		var err error
+		if writerDone, err = bb.enqueue(out); err != nil {
+			return
+		}
 	}
 
-	return nil
+	select {
+	case <-done:
+	case <-writerDone:
+	case <-time.After(partFlushTimeout):
+		bb.logger().Errorf("timed out parting %s", strings.Join(channels, ", "))
+	}
 }
 
-// Disconnect will disconnect from the twitch channel connected
-func (bb *BasicBot) Disconnect() {
-	bb.conn.Close()
-	// upTime := time.Now().Sub(bb.startTime).Seconds()
-	fmt.Printf("[%s] Closed connection from %s | Live for:", timeStamp(), bb.Server)
+// closeConn closes the connection without parting the channels, for when it's already failed
+func (bb *BasicBot) closeConn() {
+	bb.connMu.Lock()
+	conn := bb.conn
+	closed := bb.closed
+	if conn != nil && !closed {
+		bb.closed = true
+		bb.liveFor = time.Since(bb.startTime)
+	}
+	upTime := bb.liveFor
+	bb.connMu.Unlock()
+	if conn == nil || closed {
+		return
+	}
+
+	bb.setState(StateDisconnected)
+	bb.stopWriting()
+	conn.Close()
+	bb.logger().Infof("Closed connection from %s | Live for: %s", bb.server(), upTime.Round(time.Second))
+}
+
+// Shutdown stops the bot for good: StartContext stops reconnecting and returns nil, and the
+// connection is closed as Disconnect does. Disconnect alone only drops the connection, which
+// StartContext reconnects.
+func (bb *BasicBot) Shutdown() {
+	bb.connMu.Lock()
+	shutdown := bb.shutdown
+	bb.connMu.Unlock()
+	if shutdown != nil {
+		shutdown()
+	}
+	bb.Disconnect()
 }
 
-func timeStamp() string {
-	return TimeStamp(PSTFormat)
+// Uptime returns how long the bot has been connected, or how long the last connection stayed up
+// once disconnected. It is zero before the first Connect.
+func (bb *BasicBot) Uptime() time.Duration {
+	bb.connMu.Lock()
+	defer bb.connMu.Unlock()
+
+	if bb.startTime.IsZero() {
+		return 0
+	}
+	if bb.closed {
+		return bb.liveFor
+	}
+	return time.Since(bb.startTime)
+}
+
+// Dialer makes network connections, like net.Dialer and proxy dialers do
+type Dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+func (bb *BasicBot) dialer() Dialer {
+	if bb.Dialer != nil {
+		return bb.Dialer
+	}
+	return &net.Dialer{Timeout: bb.connectTimeout()}
+}
+
+func (bb *BasicBot) connectTimeout() time.Duration {
+	if bb.ConnectTimeout > 0 {
+		return bb.ConnectTimeout
+	}
+	return DefaultConnectTimeout
+}
+
+// tlsConfig returns the configuration for TLS connections. Certificates are always verified
+// against the server name unless TLSConfig explicitly disables it.
+func (bb *BasicBot) tlsConfig() *tls.Config {
+	config := &tls.Config{}
+	if bb.TLSConfig != nil {
+		config = bb.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = bb.server()
+	}
+	return config
 }
 
-// TimeStamp formats the time
+func (bb *BasicBot) readTimeout() time.Duration {
+	if bb.ReadTimeout <= 0 {
+		return DefaultReadTimeout
+	}
+	return bb.ReadTimeout
+}
+
+func (bb *BasicBot) writeTimeout() time.Duration {
+	if bb.WriteTimeout <= 0 {
+		return DefaultWriteTimeout
+	}
+	return bb.WriteTimeout
+}
+
+// timeStamp formats t in TimestampFormat, in the time zone loc, or local time when it's nil
+func timeStamp(t time.Time, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(TimestampFormat)
+}
+
+// TimeStamp formats the time, in local time
 func TimeStamp(format string) string {
 	return time.Now().Format(format)
 }
 
-func maintainWsConn() {
-	// ping := `{ "type": "PING" }`
-	for {
-		fmt.Println("sending ping")
-
-		time.Sleep(time.Minute * 5)
+// TimeStamp formats the time in the bot's TimeZone
+func (bb *BasicBot) TimeStamp(format string) string {
+	now := time.Now()
+	if bb.TimeZone != nil {
+		now = now.In(bb.TimeZone)
 	}
+	return now.Format(format)
 }
@@ -1,11 +1,833 @@
 package bot
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var bb BasicBot
 
 func TestHandleChatPrivMsg(t *testing.T) {
-	handleChatPrivMsg([]string{"cheer100", "hello", "test", "third"}, &bb)
+	handleChatPrivMsg(context.Background(), &Message{User: "hello", Text: "third"}, &bb)
+}
+
+func TestRegisterCommand(t *testing.T) {
+	b := NewBot("channel", "bot")
+
+	var gotUser string
+	b.RegisterCommand("Hello", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		gotUser = msg.User
+		return nil
+	})
+
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Text: "!HELLO"}, b)
+	if gotUser != "viewer" {
+		t.Errorf("handler not invoked, got user %q", gotUser)
+	}
+}
+
+func TestHandlerRawArgs(t *testing.T) {
+	b := NewBot("channel", "bot")
+
+	var got *Command
+	b.RegisterCommand("echo", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		got = cmd
+		return nil
+	})
+
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Text: "!echo a   b "}, b)
+	if got == nil || got.RawArgs != "a   b" || !reflect.DeepEqual(got.Args, []string{"a", "b"}) {
+		t.Errorf("handler got %+v, want RawArgs %q", got, "a   b")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text    string
+		name    string
+		args    []string
+		rawArgs string
+	}{
+		{"!so @someuser check out their channel", "so", []string{"@someuser", "check", "out", "their", "channel"}, "@someuser check out their channel"},
+		{"  !uptime  ", "uptime", []string{}, ""},
+		{"!repeat   hello   world  ", "repeat", []string{"hello", "world"}, "hello   world"},
+	}
+
+	for _, tt := range tests {
+		cmd, ok := ParseCommand(tt.text)
+		if !ok {
+			t.Fatalf("ParseCommand(%q) did not match", tt.text)
+		}
+		if cmd.Name != tt.name || cmd.RawArgs != tt.rawArgs || !reflect.DeepEqual(cmd.Args, tt.args) {
+			t.Errorf("ParseCommand(%q) = %+v", tt.text, cmd)
+		}
+	}
+
+	if _, ok := ParseCommand("just chatting"); ok {
+		t.Error("plain message parsed as a command")
+	}
+}
+
+func TestCommandPrefix(t *testing.T) {
+	b := NewBot("channel", "bot")
+	b.CommandPrefix = "?"
+
+	calls := 0
+	b.RegisterCommand("hello", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		calls++
+		return nil
+	})
+
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Text: "!hello"}, b)
+	if calls != 0 {
+		t.Error("!hello ran with the ? prefix")
+	}
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Text: "?hello"}, b)
+	if calls != 1 {
+		t.Error("?hello did not run")
+	}
+
+	if cmd, ok := ParseCommandPrefix("$$so  someone", "$$"); !ok || cmd.Name != "so" || cmd.RawArgs != "someone" {
+		t.Errorf("ParseCommandPrefix with $$ = %+v, %v", cmd, ok)
+	}
+}
+
+func TestConnectClosedPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+
+	b := BasicBot{Server: host, Port: port}
+	if err := b.Connect(); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Connect returned %v, want ErrNotConnected", err)
+	}
+}
+
+// startJoined runs b.StartContext(ctx) against a local server, returning the server's end of the
+// connection once b has joined its channel, and the channel StartContext's result is sent on
+func startJoined(t *testing.T, ctx context.Context, b *BasicBot) (net.Conn, *bufio.Reader, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	b.Server, b.Port, _ = net.SplitHostPort(ln.Addr().String())
+
+	credPath := filepath.Join(t.TempDir(), "creds.json")
+	os.WriteFile(credPath, []byte(`{"password": "oauth:token"}`), 0600)
+	b.PrivatePath = credPath
+
+	result := make(chan error, 1)
+	go func() { result <- b.StartContext(ctx) }()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "CAP REQ") {
+			conn.Write([]byte(":tmi.twitch.tv CAP * ACK :twitch.tv/tags twitch.tv/commands twitch.tv/membership\r\n"))
+		}
+		if strings.HasPrefix(line, "JOIN") {
+			return conn, r, result
+		}
+	}
+}
+
+func TestStartContextCancel(t *testing.T) {
+	b := &BasicBot{Channel: "channel", Name: "bot", Logger: NopLogger{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	_, r, result := startJoined(t, ctx, b)
+	cancel()
+
+	line, err := r.ReadString('\n')
+	if err != nil || line != "PART #channel\r\n" {
+		t.Errorf("got %q, %v; want PART line", line, err)
+	}
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("StartContext returned %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartContext did not return after cancel")
+	}
+}
+
+func TestShutdownCommand(t *testing.T) {
+	b := NewBot("channel", "bot")
+	b.Logger = NopLogger{}
+	conn, r, result := startJoined(t, context.Background(), b)
+	conn.Write([]byte(":channel!channel@channel.tmi.twitch.tv PRIVMSG #channel :!tbdown\r\n"))
+
+	line, err := r.ReadString('\n')
+	if err != nil || line != "PART #channel\r\n" {
+		t.Errorf("got %q, %v; want PART line", line, err)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("StartContext returned %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartContext reconnected instead of shutting down")
+	}
+}
+
+func TestOnCheer(t *testing.T) {
+	b := NewBot("channel", "bot")
+	b.Logger = NopLogger{}
+
+	var cheered int
+	b.OnCheer = func(user string, bits int, message string) {
+		cheered += bits
+	}
+	var commanded bool
+	b.RegisterCommand("sr", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		commanded = true
+		return nil
+	})
+
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Text: "!sr some song Cheer100", Bits: 100}, b)
+	if cheered != 100 || !commanded {
+		t.Errorf("cheered %d bits, command ran: %v", cheered, commanded)
+	}
+
+	// a nil callback is a no-op
+	b.OnCheer = nil
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Text: "Cheer100", Bits: 100}, b)
+}
+
+func TestRegisterChannelCommand(t *testing.T) {
+	b := NewMultiChannelBot([]string{"one", "two"}, "bot")
+	b.Logger = NopLogger{}
+
+	var ran []string
+	b.RegisterCommand("hi", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		ran = append(ran, "global:"+msg.Channel)
+		return nil
+	})
+	b.RegisterChannelCommand("two", "hi", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		ran = append(ran, "two:"+msg.Channel)
+		return nil
+	})
+
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "one", Text: "!hi"}, b)
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "two", Text: "!hi"}, b)
+
+	want := []string{"global:one", "two:two"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestRegisterAlias(t *testing.T) {
+	b := &BasicBot{Channel: "owner", Logger: NopLogger{}}
+	var ran []string
+	b.RegisterCommandFor(PermModerator, "shoutout", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		ran = append(ran, cmd.Args[0])
+		return nil
+	})
+	b.SetGlobalCommandCooldown("shoutout", time.Minute)
+	if err := b.RegisterAlias("so", "shoutout"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterAlias("S", "so"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range []error{
+		b.RegisterAlias("shoutout", "so"),
+		b.RegisterAlias("so", "s"),
+		b.RegisterAlias("loop", "loop"),
+	} {
+		if err == nil {
+			t.Error("expected an error for a cycle or an alias named after a command")
+		}
+	}
+
+	mod := map[string]string{"mod": "1"}
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!so ignored"}, b)
+	handleChatPrivMsg(context.Background(), &Message{User: "mod", Channel: "owner", Tags: mod, Text: "!s first"}, b)
+	// the cooldown started by !s applies to every name
+	handleChatPrivMsg(context.Background(), &Message{User: "mod", Channel: "owner", Tags: mod, Text: "!shoutout cooling"}, b)
+	handleChatPrivMsg(context.Background(), &Message{User: "mod", Channel: "other", Tags: mod, Text: "!SO second"}, b)
+
+	if want := []string{"first", "second"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestChatLoggedAtSentTime(t *testing.T) {
+	var out bytes.Buffer
+	b := &BasicBot{Channel: "channel", Logger: NewStdLogger(&out, false)}
+	sent := time.Now().Add(-time.Hour)
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "channel", Text: "late", SentAt: sent}, b)
+
+	if want := "[" + sent.Format(TimestampFormat) + "] #channel viewer: late\n"; out.String() != want {
+		t.Errorf("logged %q, want %q", out.String(), want)
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	sent := time.Date(2023, time.March, 1, 23, 30, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	logger := NewStdLogger(&out, false)
+	logger.Location = zone
+	b := &BasicBot{Channel: "channel", Logger: logger, TimeZone: zone}
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "channel", Text: "hi", SentAt: sent}, b)
+
+	if want := "[2 Mar 08:30:00] #channel viewer: hi\n"; out.String() != want {
+		t.Errorf("logged %q, want %q", out.String(), want)
+	}
+	if got := b.TimeStamp("-0700"); got != "+0900" {
+		t.Errorf("TimeStamp in zone %s, want +0900", got)
+	}
+}
+
+func TestOnUnknownCommand(t *testing.T) {
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	unknown := make(chan string, 4)
+	b.OnUnknownCommand = func(bb *BasicBot, msg *Message, cmd *Command) {
+		unknown <- msg.User + ":" + cmd.Name + ":" + strings.Join(cmd.Args, ",")
+	}
+
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!nope a b"}, b)
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "no command here"}, b)
+	// registered, just not for viewers
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!repeat hi"}, b)
+
+	select {
+	case got := <-unknown:
+		if got != "viewer:nope:a,b" {
+			t.Errorf("OnUnknownCommand got %q, want viewer:nope:a,b", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnUnknownCommand not called")
+	}
+	select {
+	case got := <-unknown:
+		t.Errorf("OnUnknownCommand called again with %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestJoinChannelMultiple(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{Channel: "one", Channels: []string{"two", "one"}, Name: "bot", Credentials: &OAuthCred{Password: "oauth:token"}, Capabilities: []string{}, Logger: NopLogger{}}
+	b.setConn(client)
+	defer b.stopWriting()
+	go b.JoinChannel()
+
+	r := bufio.NewReader(server)
+	var joins []string
+	for len(joins) < 2 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "JOIN") {
+			joins = append(joins, line)
+		}
+	}
+	if want := []string{"JOIN #one\r\n", "JOIN #two\r\n"}; !reflect.DeepEqual(joins, want) {
+		t.Errorf("joins = %q, want %q", joins, want)
+	}
+}
+
+func TestJoinChannelLowercase(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "SomeStreamer", Name: "MyBot", Credentials: &OAuthCred{Password: "oauth:token"}, Capabilities: []string{}, Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.stopWriting()
+	b.JoinChannel()
+
+	conn.waitFor(t, "NICK mybot\r\nJOIN #somestreamer\r\n")
+	if b.Name != "MyBot" {
+		t.Errorf("Name changed to %q", b.Name)
+	}
+}
+
+func TestHandleChatReadTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{Channel: "channel", ReadTimeout: 50 * time.Millisecond, Logger: NopLogger{}}
+	b.setConn(client)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrDisconnected) {
+			t.Errorf("HandleChat returned %v, want ErrDisconnected", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HandleChat did not give up on a silent connection")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	b := &BasicBot{Server: "irc.chat.twitch.tv", UseTLS: true}
+	config := b.tlsConfig()
+	if config.ServerName != "irc.chat.twitch.tv" || config.InsecureSkipVerify {
+		t.Errorf("ServerName %q, InsecureSkipVerify %v", config.ServerName, config.InsecureSkipVerify)
+	}
+
+	b.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	config = b.tlsConfig()
+	if config.ServerName != "irc.chat.twitch.tv" || !config.InsecureSkipVerify {
+		t.Errorf("opted in: ServerName %q, InsecureSkipVerify %v", config.ServerName, config.InsecureSkipVerify)
+	}
+	if b.TLSConfig.ServerName != "" {
+		t.Error("TLSConfig was modified")
+	}
+}
+
+func TestSayErrors(t *testing.T) {
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}}
+	if err := b.Say("channel", ""); !errors.Is(err, ErrEmptyMessage) {
+		t.Errorf("Say with an empty message returned %v, want ErrEmptyMessage", err)
+	}
+	if err := b.Say("channel", "hello"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Say before Connect returned %v, want ErrNotConnected", err)
+	}
+}
+
+func TestDisconnectTwice(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	b := &BasicBot{Logger: NopLogger{}}
+	b.setConn(client)
+	b.Disconnect()
+	b.Disconnect()
+
+	if b.connected() {
+		t.Error("still connected after Disconnect")
+	}
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Error("expected the connection to be closed")
+	}
+}
+
+func TestDisconnectBeforeConnect(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	b.Disconnect()
+	b.Disconnect()
+}
+
+func TestUptime(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	if got := b.Uptime(); got != 0 {
+		t.Errorf("Uptime before Connect = %s, want 0", got)
+	}
+
+	client, server := net.Pipe()
+	defer server.Close()
+	b.setConn(client)
+	time.Sleep(10 * time.Millisecond)
+	if got := b.Uptime(); got < 10*time.Millisecond {
+		t.Errorf("Uptime while connected = %s, want at least 10ms", got)
+	}
+
+	b.Disconnect()
+	final := b.Uptime()
+	time.Sleep(10 * time.Millisecond)
+	if got := b.Uptime(); got != final {
+		t.Errorf("Uptime after Disconnect = %s, want it frozen at %s", got, final)
+	}
+}
+
+func TestUptimeCommand(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.setConn(client)
+	defer b.stopWriting()
+
+	go handleChatPrivMsg(context.Background(), &Message{Type: "PRIVMSG", User: "owner", Channel: "owner", Text: "!uptime"}, b)
+
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(server).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, "PRIVMSG #owner :") || !strings.Contains(line, "Live for") {
+		t.Errorf("got %q, want the uptime sent to #owner", line)
+	}
+}
+
+func TestRepeatCommand(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.setConn(client)
+	defer b.stopWriting()
+
+	go handleChatPrivMsg(context.Background(), &Message{Type: "PRIVMSG", User: "owner", Channel: "owner", Text: "!repeat hello   world"}, b)
+
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(server).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "PRIVMSG #owner :hello   world\r\n" {
+		t.Errorf("got %q, want the arguments repeated as sent", line)
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	// a non-routable address, where connecting hangs until the timeout
+	b := BasicBot{Server: "10.255.255.1", Port: "6667", ConnectTimeout: 100 * time.Millisecond, Logger: NopLogger{}}
+
+	if d, ok := b.dialer().(*net.Dialer); !ok || d.Timeout != b.ConnectTimeout {
+		t.Errorf("default dialer %#v doesn't use ConnectTimeout", b.dialer())
+	}
+
+	start := time.Now()
+	err := b.Connect()
+	if err == nil {
+		b.Disconnect()
+		t.Skip("the network accepted a connection to a non-routable address")
+	}
+	if !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Connect returned %v, want ErrNotConnected", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Connect took %s, want about the 100ms timeout", elapsed)
+	}
+}
+
+func TestReply(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	m, err := ParseMessage("@id=b34ccfc7-4977-403a-8a94-33c6bac34fb8 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!dice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reply("channel", m.ID, "you rolled 4"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "@reply-parent-msg-id=b34ccfc7-4977-403a-8a94-33c6bac34fb8 PRIVMSG #channel :you rolled 4\r\n")
+
+	if err := b.Reply("channel", "", "no parent"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "\r\nPRIVMSG #channel :no parent\r\n")
+}
+
+type dialFunc func(network, addr string) (net.Conn, error)
+
+func (f dialFunc) Dial(network, addr string) (net.Conn, error) { return f(network, addr) }
+
+func TestLifecycleCallbacks(t *testing.T) {
+	conns := make(chan *fakeConn, 2)
+	connected := make(chan struct{}, 2)
+	disconnected := make(chan error, 2)
+	b := &BasicBot{
+		Channel:       "channel",
+		Anonymous:     true,
+		Capabilities:  []string{},
+		ReconnectBase: time.Millisecond,
+		Logger:        NopLogger{},
+		Dialer: dialFunc(func(network, addr string) (net.Conn, error) {
+			conn := newFakeConn()
+			conns <- conn
+			return conn, nil
+		}),
+		OnConnect: func() {
+			connected <- struct{}{}
+			panic("callbacks can't take down the bot")
+		},
+		OnDisconnect: func(err error) { disconnected <- err },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- b.StartContext(ctx) }()
+
+	wait := func(ch <-chan struct{}) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("OnConnect not called")
+		}
+	}
+	waitErr := func() error {
+		t.Helper()
+		select {
+		case err := <-disconnected:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("OnDisconnect not called")
+		}
+		return nil
+	}
+
+	wait(connected)
+	(<-conns).Close()
+	if err := waitErr(); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("OnDisconnect got %v, want ErrDisconnected", err)
+	}
+
+	wait(connected)
+	cancel()
+	if err := waitErr(); err != nil {
+		t.Errorf("OnDisconnect got %v on shutdown, want nil", err)
+	}
+	<-result
+}
+
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) Infof(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+func (l *recordLogger) Errorf(format string, v ...interface{}) { l.Infof(format, v...) }
+func (l *recordLogger) Debugf(format string, v ...interface{}) {}
+
+func TestDryRun(t *testing.T) {
+	conn := newFakeConn("PING :tmi.twitch.tv\r\n")
+	logger := &recordLogger{}
+	b := &BasicBot{Channel: "channel", DryRun: true, Logger: logger}
+	b.setConn(conn)
+	go b.HandleChat()
+	defer b.Disconnect()
+
+	if err := b.Say("channel", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reply("channel", "abc", "threaded"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Whisper("viewer", "psst"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "PONG :tmi.twitch.tv\r\n")
+
+	if strings.Contains(conn.written(), "PRIVMSG") {
+		t.Errorf("sent messages in a dry run: %q", conn.written())
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logged := strings.Join(logger.lines, "\n")
+	for _, want := range []string{"hello", "threaded", "psst"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("%q not logged, got %q", want, logged)
+		}
+	}
+}
+
+func TestLongTaggedLine(t *testing.T) {
+	// an emote repeated enough times that its positions alone overflow a 4096 byte buffer
+	var positions []string
+	for i := 0; i < 600; i++ {
+		positions = append(positions, fmt.Sprintf("%d-%d", i*6, i*6+4))
+	}
+	line := "@badges=broadcaster/1;emotes=25:" + strings.Join(positions, ",") +
+		" :owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!repeat " + strings.TrimSpace(strings.Repeat("Kappa ", 80)) + "\r\n"
+	if len(line) <= 4096 {
+		t.Fatalf("line is only %d bytes", len(line))
+	}
+
+	conn := newFakeConn(line)
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.setConn(conn)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	conn.waitFor(t, "PRIVMSG #owner :Kappa Kappa")
+	b.Disconnect()
+	<-result
+
+	if r := b.newReader(strings.NewReader("")); r.R.Size() != DefaultReadBufferSize {
+		t.Errorf("reader buffer is %d bytes, want %d", r.R.Size(), DefaultReadBufferSize)
+	}
+}
+
+func TestCommandPanic(t *testing.T) {
+	conn := newFakeConn(
+		":owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!boom\r\n",
+		":owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!repeat still here\r\n",
+	)
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.RegisterCommand("boom", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		var counts map[string]int
+		counts[msg.User]++
+		return nil
+	})
+	panicked := make(chan string, 1)
+	b.OnPanic = func(command string, recovered interface{}) { panicked <- command }
+	b.setConn(conn)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	// the message after the panic is still read and answered
+	conn.waitFor(t, "PRIVMSG #owner :still here\r\n")
+	select {
+	case err := <-result:
+		t.Fatalf("stopped reading after the panic: %v", err)
+	default:
+	}
+	select {
+	case command := <-panicked:
+		if command != "boom" {
+			t.Errorf("OnPanic called for %q, want boom", command)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("OnPanic not called")
+	}
+	b.Disconnect()
+	<-result
+}
+
+func TestCommandTimeout(t *testing.T) {
+	logger := &recordLogger{}
+	b := NewBot("channel", "bot")
+	b.Logger = logger
+	b.CommandTimeout = 10 * time.Millisecond
+
+	var handlerErr error
+	b.RegisterCommand("slow", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		select {
+		case <-ctx.Done():
+			handlerErr = ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+		return nil
+	})
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "channel", Text: "!slow"}, b)
+
+	if !errors.Is(handlerErr, context.DeadlineExceeded) {
+		t.Errorf("handler's context ended with %v, want DeadlineExceeded", handlerErr)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if last := logger.lines[len(logger.lines)-1]; !strings.Contains(last, "over the 10ms CommandTimeout") {
+		t.Errorf("overrun not logged, last line %q", last)
+	}
+}
+
+func TestShouldReconnect(t *testing.T) {
+	errBlip := errors.New("connection reset by peer")
+	errFatal := errors.New("banned from the server")
+
+	dials := 0
+	b := &BasicBot{
+		Channel:       "channel",
+		Anonymous:     true,
+		ReconnectBase: time.Millisecond,
+		Logger:        NopLogger{},
+		Dialer: dialFunc(func(network, addr string) (net.Conn, error) {
+			dials++
+			if dials < 3 {
+				return nil, errBlip
+			}
+			return nil, errFatal
+		}),
+		ShouldReconnect: func(err error) bool { return !errors.Is(err, errFatal) },
+	}
+
+	// retries the blips, then stops at the fatal error
+	if err := b.StartContext(context.Background()); !errors.Is(err, errFatal) {
+		t.Errorf("StartContext returned %v, want the fatal error", err)
+	}
+	if dials != 3 {
+		t.Errorf("dialed %d times, want 3", dials)
+	}
+
+	if !DefaultShouldReconnect(errBlip) || !DefaultShouldReconnect(fmt.Errorf("read: %w", ErrDisconnected)) {
+		t.Error("DefaultShouldReconnect gave up on a network error")
+	}
+	if DefaultShouldReconnect(fmt.Errorf("login: %w", ErrAuthFailed)) || DefaultShouldReconnect(context.Canceled) {
+		t.Error("DefaultShouldReconnect retries fatal errors")
+	}
+}
+
+func TestSayInjection(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	payload := "hi\r\nPRIVMSG #channel :/ban streamer\r\nPART #channel"
+	if err := b.Say("channel", payload); !errors.Is(err, ErrInvalidCharacters) {
+		t.Errorf("Say returned %v, want ErrInvalidCharacters", err)
+	}
+	if err := b.Say("channel", "nul\x00byte"); !errors.Is(err, ErrInvalidCharacters) {
+		t.Errorf("Say with NUL returned %v, want ErrInvalidCharacters", err)
+	}
+	if err := b.Reply("channel", "abc", payload); !errors.Is(err, ErrInvalidCharacters) {
+		t.Errorf("Reply returned %v, want ErrInvalidCharacters", err)
+	}
+	if err := b.Reply("channel", "abc :x PART #channel", "hi"); err == nil {
+		t.Error("Reply accepted a message id with spaces")
+	}
+	if err := b.Whisper("viewer", payload); !errors.Is(err, ErrInvalidCharacters) {
+		t.Errorf("Whisper returned %v, want ErrInvalidCharacters", err)
+	}
+
+	// nothing was written, and a clean message still goes out
+	if err := b.Say("channel", "safe"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "PRIVMSG #channel :safe\r\n")
+	if written := conn.written(); written != "PRIVMSG #channel :safe\r\n" {
+		t.Errorf("wrote %q", written)
+	}
 }
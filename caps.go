@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultCapabilities are the Twitch IRC capabilities requested when BasicBot.Capabilities is nil
+var DefaultCapabilities = []string{"twitch.tv/tags", "twitch.tv/commands", "twitch.tv/membership"}
+
+// how long to wait for the server to answer a CAP REQ
+const capAckTimeout = 10 * time.Second
+
+func (bb *BasicBot) capabilities() []string {
+	if bb.Capabilities == nil {
+		return DefaultCapabilities
+	}
+	return bb.Capabilities
+}
+
+// requestCapabilities sends a CAP REQ for the configured capabilities and waits for the server to
+// acknowledge it. Nothing is sent if no capabilities are configured.
+func (bb *BasicBot) requestCapabilities() error {
+	caps := bb.capabilities()
+	if len(caps) == 0 {
+		return nil
+	}
+
+	bb.send("CAP REQ :" + strings.Join(caps, " ") + "\r\n")
+
+	bb.conn.SetReadDeadline(time.Now().Add(capAckTimeout))
+	defer bb.conn.SetReadDeadline(time.Time{})
+
+	for {
+		line, err := bb.reader.ReadLine()
+		if err != nil {
+			return fmt.Errorf("BasicBot.requestCapabilities: waiting for CAP ACK: %w", err)
+		}
+		bb.logger().Debugf("%s", line)
+
+		// :tmi.twitch.tv CAP * ACK :twitch.tv/tags twitch.tv/commands
+		msg, err := ParseMessage(line)
+		if err != nil || msg.Type != "CAP" || len(msg.Params) < 2 {
+			continue
+		}
+		switch msg.Params[1] {
+		case "ACK":
+			return nil
+		case "NAK":
+			return fmt.Errorf("BasicBot.requestCapabilities: capabilities rejected: %s", strings.Join(caps, " "))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"testing"
+)
+
+func TestRequestCapabilities(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{reader: textproto.NewReader(bufio.NewReader(client)), Logger: NopLogger{}, Capabilities: []string{"twitch.tv/tags"}}
+	b.setConn(client)
+	defer b.stopWriting()
+
+	got := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(server).ReadString('\n')
+		got <- line
+		server.Write([]byte(":tmi.twitch.tv CAP * ACK :twitch.tv/tags\r\n"))
+	}()
+
+	if err := b.requestCapabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-got; line != "CAP REQ :twitch.tv/tags\r\n" {
+		t.Errorf("sent %q", line)
+	}
+}
+
+func TestRequestCapabilitiesNak(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{reader: textproto.NewReader(bufio.NewReader(client)), Logger: NopLogger{}, Capabilities: []string{"twitch.tv/bogus"}}
+	b.setConn(client)
+	defer b.stopWriting()
+
+	go func() {
+		bufio.NewReader(server).ReadString('\n')
+		server.Write([]byte(":tmi.twitch.tv CAP * NAK :twitch.tv/bogus\r\n"))
+	}()
+
+	if err := b.requestCapabilities(); err == nil {
+		t.Error("expected an error for a NAK")
+	}
+}
+
+func TestRequestCapabilitiesNone(t *testing.T) {
+	// nothing reads the other end of the pipe, so any write would block
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{conn: client, Logger: NopLogger{}, Capabilities: []string{}}
+	if err := b.requestCapabilities(); err != nil {
+		t.Fatal(err)
+	}
+}
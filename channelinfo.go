@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// how long the bot's Helix calls wait, at most, when their context doesn't end sooner
+const helixTimeout = 10 * time.Second
+
+// HelixGame is a category on Twitch
+type HelixGame struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetGames looks up categories by their exact names
+func (h *HelixClient) GetGames(ctx context.Context, names ...string) ([]HelixGame, error) {
+	var games []HelixGame
+	err := h.do(ctx, http.MethodGet, "/games", url.Values{"name": names}, nil, &games)
+	return games, err
+}
+
+// ModifyChannel changes the title and category of the channel of broadcasterID, leaving those
+// that are empty as they are. The token must have the channel:manage:broadcast scope.
+func (h *HelixClient) ModifyChannel(ctx context.Context, broadcasterID, title, gameID string) error {
+	body := struct {
+		Title  string `json:"title,omitempty"`
+		GameID string `json:"game_id,omitempty"`
+	}{title, gameID}
+	err := h.do(ctx, http.MethodPatch, "/channels", url.Values{"broadcaster_id": {broadcasterID}}, body, nil)
+	return missingScope(err, "channel:manage:broadcast")
+}
+
+// missingScope wraps err with ErrMissingScope when Helix rejected the request for lacking scope
+func missingScope(err error, scope string) error {
+	var helixErr *HelixError
+	if errors.As(err, &helixErr) && strings.Contains(strings.ToLower(helixErr.Message), "scope") {
+		return fmt.Errorf("%w %s: %w", ErrMissingScope, scope, err)
+	}
+	return err
+}
+
+// broadcasterID resolves the user id of the owner of channel
+func (h *HelixClient) broadcasterID(ctx context.Context, channel string) (string, error) {
+	users, err := h.GetUsers(ctx, channel)
+	if err != nil {
+		return "", err
+	}
+	if len(users) == 0 {
+		return "", fmt.Errorf("no user named %s", channel)
+	}
+	return users[0].ID, nil
+}
+
+// userIDs resolves the user ids of logins, in the same order
+func (h *HelixClient) userIDs(ctx context.Context, logins ...string) ([]string, error) {
+	users, err := h.GetUsers(ctx, logins...)
+	if err != nil {
+		return nil, err
+	}
+	byLogin := make(map[string]string, len(users))
+	for _, user := range users {
+		byLogin[strings.ToLower(user.Login)] = user.ID
+	}
+	ids := make([]string, len(logins))
+	for i, login := range logins {
+		if ids[i] = byLogin[strings.ToLower(login)]; ids[i] == "" {
+			return nil, fmt.Errorf("no user named %s", login)
+		}
+	}
+	return ids, nil
+}
+
+// SetTitle changes the stream title of channel. The bot's token must belong to the broadcaster or
+// one of their editors and have the channel:manage:broadcast scope.
+func (bb *BasicBot) SetTitle(channel, title string) error {
+	return bb.SetTitleContext(context.Background(), channel, title)
+}
+
+// SetTitleContext is SetTitle with a context, which cancels the requests to Helix when it's done
+func (bb *BasicBot) SetTitleContext(ctx context.Context, channel, title string) error {
+	if title == "" {
+		return errors.New("BasicBot.SetTitle: title was empty")
+	}
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	id, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("BasicBot.SetTitle: %w", err)
+	}
+	if err := helix.ModifyChannel(ctx, id, title, ""); err != nil {
+		return fmt.Errorf("BasicBot.SetTitle: %w", err)
+	}
+	return nil
+}
+
+// SetGame changes the category of channel to the one called name, like SetTitle
+func (bb *BasicBot) SetGame(channel, name string) error {
+	return bb.SetGameContext(context.Background(), channel, name)
+}
+
+// SetGameContext is SetGame with a context, like SetTitleContext
+func (bb *BasicBot) SetGameContext(ctx context.Context, channel, name string) error {
+	if name == "" {
+		return errors.New("BasicBot.SetGame: name was empty")
+	}
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	games, err := helix.GetGames(ctx, name)
+	if err != nil {
+		return fmt.Errorf("BasicBot.SetGame: %w", err)
+	}
+	if len(games) == 0 {
+		return fmt.Errorf("BasicBot.SetGame: no category called %q", name)
+	}
+	id, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("BasicBot.SetGame: %w", err)
+	}
+	if err := helix.ModifyChannel(ctx, id, "", games[0].ID); err != nil {
+		return fmt.Errorf("BasicBot.SetGame: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetGame(t *testing.T) {
+	var patched map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/games":
+			if r.URL.Query().Get("name") != "Elden Ring" {
+				t.Errorf("looked up %q", r.URL.Query().Get("name"))
+			}
+			w.Write([]byte(`{"data":[{"id":"512953","name":"Elden Ring"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/channels":
+			if r.URL.Query().Get("broadcaster_id") != "123" {
+				t.Errorf("patched broadcaster %q", r.URL.Query().Get("broadcaster_id"))
+			}
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL}
+	if err := b.SetGame("dallas", "Elden Ring"); err != nil {
+		t.Fatal(err)
+	}
+	if patched["game_id"] != "512953" || patched["title"] != "" {
+		t.Errorf("patched %v", patched)
+	}
+}
+
+func TestSetTitleMissingScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Missing scope: channel:manage:broadcast"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL}
+	if err := b.SetTitle("dallas", "new title"); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("SetTitle returned %v, want ErrMissingScope", err)
+	}
+}
+
+func TestTitleCommandUsesHandlerContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	logger := &recordLogger{}
+	b := NewBot("channel", "bot")
+	b.Credentials = &OAuthCred{Password: "oauth:token", ClientID: "client"}
+	b.HelixURL = srv.URL
+	b.Logger = logger
+	b.CommandTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	handleChatPrivMsg(context.Background(), &Message{User: "channel", Channel: "channel", Text: "!title new title"}, b)
+	if elapsed := time.Since(start); elapsed > helixTimeout/2 {
+		t.Errorf("!title took %s, CommandTimeout didn't reach the request", elapsed)
+	}
+	if !logger.contains("context deadline exceeded") {
+		t.Errorf("expected the handler's deadline in the error, logged %q", logger.lines)
+	}
+}
+
+func TestTitleCommand(t *testing.T) {
+	var patched map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/channels":
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	b := NewBot("dallas", "bot")
+	b.Credentials = &OAuthCred{Password: "oauth:token", ClientID: "client"}
+	b.HelixURL = srv.URL
+	b.DryRun = true
+	b.Logger = NopLogger{}
+
+	handleChatPrivMsg(context.Background(), &Message{User: "dallas", Channel: "dallas", Text: "!title speedrun  ::  any%"}, b)
+	// the title is kept as typed
+	if patched["title"] != "speedrun  ::  any%" {
+		t.Errorf("patched %v", patched)
+	}
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NormalizeChannel returns channel the way the bot keeps it: without the leading "#", and in
+// lowercase like the Twitch login it's named after, so "#Foo", "Foo" and "foo" are the same channel
+func NormalizeChannel(channel string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(channel), "#"))
+}
+
+// IRCChannel returns channel normalized and with the leading "#" it's written with in IRC lines
+func IRCChannel(channel string) string {
+	return "#" + NormalizeChannel(channel)
+}
+
+// normalizeChannels returns channels, normalized
+func normalizeChannels(channels []string) []string {
+	if channels == nil {
+		return nil
+	}
+	normalized := make([]string, len(channels))
+	for i, channel := range channels {
+		normalized[i] = NormalizeChannel(channel)
+	}
+	return normalized
+}
+
+// channels returns the channels the bot is in, or is configured to join before it first connects
+func (bb *BasicBot) channels() []string {
+	bb.chanMu.Lock()
+	defer bb.chanMu.Unlock()
+
+	if bb.joined != nil {
+		return append([]string(nil), bb.joined...)
+	}
+	return bb.configuredChannels()
+}
+
+// configuredChannels returns Channel and Channels without duplicates
+func (bb *BasicBot) configuredChannels() []string {
+	channels := []string{}
+	seen := make(map[string]bool)
+	for _, channel := range append([]string{bb.Channel}, bb.Channels...) {
+		channel = NormalizeChannel(channel)
+		if channel != "" && !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// rememberChannels seeds the set of joined channels from the configuration the first time the
+// bot joins, and returns the channels to join
+func (bb *BasicBot) rememberChannels() []string {
+	bb.chanMu.Lock()
+	defer bb.chanMu.Unlock()
+
+	if bb.joined == nil {
+		bb.joined = bb.configuredChannels()
+	}
+	return append([]string(nil), bb.joined...)
+}
+
+// connected reports whether the bot has a live connection to write to
+func (bb *BasicBot) connected() bool {
+	bb.connMu.Lock()
+	defer bb.connMu.Unlock()
+
+	return bb.conn != nil && bb.stopWriter != nil
+}
+
+// Join joins channel over the live connection. The channel is rejoined on reconnect until Part is
+// called for it.
+func (bb *BasicBot) Join(channel string) error {
+	channel = NormalizeChannel(channel)
+	if channel == "" {
+		return errors.New("BasicBot.Join: channel was empty")
+	}
+	if !bb.connected() {
+		return fmt.Errorf("BasicBot.Join: cannot join #%s: %w", channel, ErrNotConnected)
+	}
+
+	bb.rememberChannels()
+	bb.chanMu.Lock()
+	for _, joined := range bb.joined {
+		if joined == channel {
+			bb.chanMu.Unlock()
+			return nil
+		}
+	}
+	bb.joined = append(bb.joined, channel)
+	bb.chanMu.Unlock()
+
+	bb.logger().Infof("Joining #%s...", channel)
+	if err := bb.send("JOIN " + IRCChannel(channel) + "\r\n"); err != nil {
+		return fmt.Errorf("BasicBot.Join: cannot join #%s: %w", channel, err)
+	}
+	return nil
+}
+
+// Part leaves channel over the live connection
+func (bb *BasicBot) Part(channel string) error {
+	channel = NormalizeChannel(channel)
+	if channel == "" {
+		return errors.New("BasicBot.Part: channel was empty")
+	}
+	if !bb.connected() {
+		return fmt.Errorf("BasicBot.Part: cannot part #%s: %w", channel, ErrNotConnected)
+	}
+
+	bb.rememberChannels()
+	bb.chanMu.Lock()
+	for i, joined := range bb.joined {
+		if joined == channel {
+			bb.joined = append(bb.joined[:i], bb.joined[i+1:]...)
+			break
+		}
+	}
+	bb.chanMu.Unlock()
+
+	bb.logger().Infof("Parting #%s...", channel)
+	if err := bb.send("PART " + IRCChannel(channel) + "\r\n"); err != nil {
+		return fmt.Errorf("BasicBot.Part: cannot part #%s: %w", channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJoinPart(t *testing.T) {
+	b := &BasicBot{Channel: "one", Logger: NopLogger{}}
+	if err := b.Join("two"); err == nil {
+		t.Error("expected an error joining while not connected")
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	b.setConn(client)
+	defer b.stopWriting()
+
+	r := bufio.NewReader(server)
+	expect := func(want string) {
+		t.Helper()
+		line, err := r.ReadString('\n')
+		if err != nil || line != want {
+			t.Errorf("got %q, %v; want %q", line, err, want)
+		}
+	}
+
+	if err := b.Join("two"); err != nil {
+		t.Fatal(err)
+	}
+	expect("JOIN #two\r\n")
+	if err := b.Part("one"); err != nil {
+		t.Fatal(err)
+	}
+	expect("PART #one\r\n")
+
+	if got := b.channels(); !reflect.DeepEqual(got, []string{"two"}) {
+		t.Errorf("channels() = %v, want [two]", got)
+	}
+}
+
+func TestDisconnectParts(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "one", Channels: []string{"two"}, Name: "bot", Anonymous: true, Capabilities: []string{}, Logger: NopLogger{}}
+	b.setConn(conn)
+	b.JoinChannel()
+	conn.waitFor(t, "JOIN #two\r\n")
+	b.Disconnect()
+
+	if !strings.HasSuffix(conn.written(), "PART #one\r\nPART #two\r\n") {
+		t.Errorf("wrote %q, want PART lines before closing", conn.written())
+	}
+}
+
+func TestDisconnectOnErrorDoesNotPart(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "one", Name: "bot", Anonymous: true, Capabilities: []string{}, ReadTimeout: 10 * time.Millisecond, Logger: NopLogger{}}
+	b.setConn(conn)
+	b.JoinChannel()
+	conn.waitFor(t, "JOIN #one\r\n")
+	if err := b.HandleChat(); err == nil {
+		t.Fatal("expected the read timeout to end HandleChat")
+	}
+
+	if strings.Contains(conn.written(), "PART") {
+		t.Errorf("parted a dead connection, wrote %q", conn.written())
+	}
+}
+
+func TestChannelNormalized(t *testing.T) {
+	for _, channel := range []string{"#Foo", "Foo", "foo"} {
+		conn := newFakeConn()
+		b := &BasicBot{Channel: channel, Name: "bot", Anonymous: true, Capabilities: []string{}, Logger: NopLogger{}}
+		b.setConn(conn)
+		b.JoinChannel()
+		conn.waitFor(t, "JOIN #foo\r\n")
+		if err := b.Join(channel); err != nil {
+			t.Fatal(err)
+		}
+		b.stopWriting()
+
+		if n := strings.Count(conn.written(), "JOIN"); n != 1 {
+			t.Errorf("Channel %q: joined %d times, wrote %q", channel, n, conn.written())
+		}
+		if got := b.channels(); !reflect.DeepEqual(got, []string{"foo"}) {
+			t.Errorf("Channel %q: channels() = %v, want [foo]", channel, got)
+		}
+	}
+
+	if got := IRCChannel(" #Foo"); got != "#foo" {
+		t.Errorf("IRCChannel(%q) = %q, want #foo", " #Foo", got)
+	}
+}
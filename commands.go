@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CommandHandler is called when a user sends a registered !command in chat.
+//
+// msg is the chat message carrying the command, whose User and Channel identify who sent it and
+// where to answer, and cmd is the command parsed from it: cmd.Args are the words that followed the
+// command and cmd.RawArgs is their text as it was sent. ctx is cancelled once the handler has run
+// for CommandTimeout or the bot shuts down, which handlers making requests should pass on.
+//
+// Handlers run one at a time in the order their messages arrived, so a slow handler holds up the
+// messages after it, unless BasicBot.CommandWorkers is set. Either way a user's commands are
+// handled in the order they were sent.
+type CommandHandler func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error
+
+// Command is a !command parsed from a chat message
+type Command struct {
+	// Name of the command without the leading "!"
+	Name string
+	// Args are the words following the command name. It is empty, never nil, when there are none.
+	Args []string
+	// RawArgs is everything following the command name with surrounding whitespace trimmed
+	RawArgs string
+}
+
+// DefaultCommandPrefix is the prefix commands start with unless BasicBot.CommandPrefix is set
+const DefaultCommandPrefix = "!"
+
+// ParseCommand parses a !command and its arguments from the text of a chat message.
+// The second return value is false if the text is not a command.
+func ParseCommand(text string) (*Command, bool) {
+	return ParseCommandPrefix(text, DefaultCommandPrefix)
+}
+
+// ParseCommandPrefix is like ParseCommand for commands starting with prefix instead of "!". The
+// prefix is matched literally, so it may be any string, such as "?", "$" or "bot!".
+func ParseCommandPrefix(text, prefix string) (*Command, bool) {
+	text = strings.TrimSpace(text)
+	if prefix == "" || !strings.HasPrefix(text, prefix) {
+		return nil, false
+	}
+	matches := cmdRegex.FindStringSubmatch(text[len(prefix):])
+	if matches == nil {
+		return nil, false
+	}
+
+	raw := strings.TrimSpace(matches[2])
+	args := strings.Fields(raw)
+	if args == nil {
+		args = []string{}
+	}
+	return &Command{Name: matches[1], Args: args, RawArgs: raw}, true
+}
+
+// parseCommand parses a command starting with the bot's CommandPrefix from text
+func (bb *BasicBot) parseCommand(text string) (*Command, bool) {
+	return ParseCommandPrefix(text, bb.commandPrefix())
+}
+
+func (bb *BasicBot) commandPrefix() string {
+	if bb.CommandPrefix == "" {
+		return DefaultCommandPrefix
+	}
+	return bb.CommandPrefix
+}
+
+// NewBot creates a BasicBot for the given channel with the default commands registered
+func NewBot(channel, name string) *BasicBot {
+	bb := &BasicBot{
+		Channel: NormalizeChannel(channel),
+		Name:    name,
+	}
+	bb.registerDefaultCommands()
+	return bb
+}
+
+// NewMultiChannelBot creates a BasicBot that joins all of the given channels over a single
+// connection, with the default commands registered
+func NewMultiChannelBot(channels []string, name string) *BasicBot {
+	bb := &BasicBot{
+		Channels: normalizeChannels(channels),
+		Name:     name,
+	}
+	bb.registerDefaultCommands()
+	return bb
+}
+
+// registeredCommand is a handler with the level a user needs to run it
+type registeredCommand struct {
+	handler    CommandHandler
+	permission Permission
+}
+
+// RegisterCommand adds a handler for !name in every channel that anyone can run, replacing any
+// handler already registered under it. Command names are case-insensitive.
+func (bb *BasicBot) RegisterCommand(name string, handler CommandHandler) {
+	bb.RegisterCommandFor(PermEveryone, name, handler)
+}
+
+// RegisterCommandFor is like RegisterCommand, but the handler only runs for users with at least
+// the given permission. Commands from anyone else are ignored.
+func (bb *BasicBot) RegisterCommandFor(permission Permission, name string, handler CommandHandler) {
+	bb.registerCommand("", name, registeredCommand{handler, permission})
+}
+
+// RegisterChannelCommand adds a handler for !name that only runs for messages sent to channel.
+// It takes precedence over a handler registered with RegisterCommand under the same name.
+func (bb *BasicBot) RegisterChannelCommand(channel, name string, handler CommandHandler) {
+	bb.RegisterChannelCommandFor(channel, PermEveryone, name, handler)
+}
+
+// RegisterChannelCommandFor is like RegisterChannelCommand, but the handler only runs for users
+// with at least the given permission.
+func (bb *BasicBot) RegisterChannelCommandFor(channel string, permission Permission, name string, handler CommandHandler) {
+	bb.registerCommand(channel, name, registeredCommand{handler, permission})
+}
+
+// RegisterAlias makes !alias run !command, with its permission and sharing its cooldowns, in
+// every channel. An alias can't have the name of a command, and aliases can't lead in a circle.
+func (bb *BasicBot) RegisterAlias(alias, command string) error {
+	return bb.dispatcher().RegisterAlias(alias, command)
+}
+
+func (bb *BasicBot) registerCommand(channel, name string, cmd registeredCommand) {
+	bb.dispatcher().registerCommand(channel, name, cmd)
+}
+
+// lookupCommand finds the command !name sent to channel
+func (bb *BasicBot) lookupCommand(channel, name string) (registeredCommand, bool) {
+	return bb.dispatcher().lookupCommand(channel, name)
+}
+
+func (bb *BasicBot) registerDefaultCommands() {
+	bb.RegisterCommandFor(PermBroadcaster, "tbdown", cmdShutdown)
+	bb.RegisterCommandFor(PermBroadcaster, "repeat", cmdRepeat)
+	bb.RegisterCommandFor(PermBroadcaster, "join", cmdJoin)
+	bb.RegisterCommandFor(PermBroadcaster, "part", cmdPart)
+	bb.RegisterCommandFor(PermBroadcaster, "uptime", cmdUptime)
+	bb.RegisterCommandFor(PermBroadcaster, "title", cmdTitle)
+	bb.RegisterCommandFor(PermBroadcaster, "game", cmdGame)
+	bb.RegisterCommand("help", cmdHelp)
+
+	bb.SetCommandDescription("tbdown", "shuts the bot down")
+	bb.SetCommandDescription("repeat", "says the message given")
+	bb.SetCommandDescription("join", "joins another channel")
+	bb.SetCommandDescription("part", "leaves this channel, or the one given")
+	bb.SetCommandDescription("uptime", "how long the bot has been live")
+	bb.SetCommandDescription("title", "changes the stream title")
+	bb.SetCommandDescription("game", "changes the stream category")
+	bb.SetCommandDescription("help", "lists the commands, or describes the one given")
+}
+
+func cmdShutdown(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	bb.logger().Infof("Shutdown command received. Shutting down now...")
+	bb.Shutdown()
+	return nil
+}
+
+func cmdRepeat(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	if cmd.RawArgs == "" {
+		return errors.New("usage: !repeat <message>")
+	}
+	return bb.Say(msg.Channel, cmd.RawArgs)
+}
+
+func cmdJoin(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("usage: !join <channel>")
+	}
+	return bb.Join(cmd.Args[0])
+}
+
+func cmdPart(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	channel := msg.Channel
+	if len(cmd.Args) > 0 {
+		channel = cmd.Args[0]
+	}
+	return bb.Part(channel)
+}
+
+func cmdUptime(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	return bb.Say(msg.Channel, fmt.Sprintf("Live for %s", bb.Uptime().Round(time.Second)))
+}
+
+func cmdTitle(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	title := cmd.RawArgs
+	if title == "" {
+		return errors.New("usage: !title <new title>")
+	}
+	if err := bb.SetTitleContext(ctx, msg.Channel, title); err != nil {
+		return err
+	}
+	return bb.Say(msg.Channel, "Title changed to: "+title)
+}
+
+func cmdGame(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	game := cmd.RawArgs
+	if game == "" {
+		return errors.New("usage: !game <category>")
+	}
+	if err := bb.SetGameContext(ctx, msg.Channel, game); err != nil {
+		return err
+	}
+	return bb.Say(msg.Channel, "Category changed to: "+game)
+}
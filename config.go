@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"errors"
+	"time"
+)
+
+// Defaults applied by NewBasicBot, and by Connect when Server or Port are empty
+const (
+	DefaultServer  = "irc.chat.twitch.tv"
+	DefaultPort    = "6667"
+	DefaultTLSPort = "6697"
+	// DefaultMsgRate keeps a bot well under Twitch's message limit
+	DefaultMsgRate = 1500 * time.Millisecond
+)
+
+// Config is what NewBasicBot needs to create a bot. Channel or Channels, and PrivatePath or
+// CredentialSource unless Anonymous, are required; everything else has a default.
+type Config struct {
+	// Channel and Channels are the channels to join, see BasicBot
+	Channel  string
+	Channels []string
+	// Name is the bot's username. Defaults to the username in the credentials.
+	Name string
+
+	// PrivatePath is the JSON file holding the credentials, unless CredentialSource is set
+	PrivatePath      string
+	CredentialSource CredentialSource
+	// Anonymous reads chat without credentials, see BasicBot.Anonymous
+	Anonymous bool
+
+	// Server and Port default to DefaultServer and DefaultPort, or DefaultTLSPort with UseTLS
+	Server string
+	Port   string
+	UseTLS bool
+
+	// MsgRate is the minimum time between chat messages. Defaults to DefaultMsgRate.
+	MsgRate time.Duration
+	// CommandPrefix starts commands in chat. Defaults to DefaultCommandPrefix.
+	CommandPrefix string
+
+	// Logger defaults to a StdLogger writing to stdout
+	Logger Logger
+}
+
+// NewBasicBot creates a bot from cfg, with the default commands registered. New with options is
+// the preferred way to create a bot, though a BasicBot set up by hand still works too.
+func NewBasicBot(cfg Config) (*BasicBot, error) {
+	if cfg.Channel == "" && len(cfg.Channels) == 0 {
+		return nil, errors.New("NewBasicBot: no channel to join, set Channel or Channels")
+	}
+	if cfg.PrivatePath == "" && cfg.CredentialSource == nil && !cfg.Anonymous {
+		return nil, errors.New("NewBasicBot: no credentials, set PrivatePath or CredentialSource")
+	}
+	if cfg.MsgRate < 0 {
+		return nil, errors.New("NewBasicBot: MsgRate is negative")
+	}
+
+	bb := &BasicBot{
+		Channel:          NormalizeChannel(cfg.Channel),
+		Channels:         normalizeChannels(cfg.Channels),
+		Name:             cfg.Name,
+		PrivatePath:      cfg.PrivatePath,
+		CredentialSource: cfg.CredentialSource,
+		Anonymous:        cfg.Anonymous,
+		Server:           cfg.Server,
+		Port:             cfg.Port,
+		UseTLS:           cfg.UseTLS,
+		MsgRate:          cfg.MsgRate,
+		CommandPrefix:    cfg.CommandPrefix,
+		Logger:           cfg.Logger,
+	}
+	bb.Server = bb.server()
+	bb.Port = bb.port()
+	if bb.MsgRate == 0 {
+		bb.MsgRate = DefaultMsgRate
+	}
+	bb.registerDefaultCommands()
+	return bb, nil
+}
+
+func (bb *BasicBot) server() string {
+	if bb.Server != "" {
+		return bb.Server
+	}
+	return DefaultServer
+}
+
+func (bb *BasicBot) port() string {
+	switch {
+	case bb.Port != "":
+		return bb.Port
+	case bb.UseTLS:
+		return DefaultTLSPort
+	}
+	return DefaultPort
+}
@@ -0,0 +1,35 @@
+package bot
+
+import "testing"
+
+func TestNewBasicBot(t *testing.T) {
+	b, err := NewBasicBot(Config{Channel: "channel", PrivatePath: "private.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Server != DefaultServer || b.Port != DefaultPort || b.MsgRate != DefaultMsgRate {
+		t.Errorf("got %s:%s every %s, want the defaults", b.Server, b.Port, b.MsgRate)
+	}
+	if _, ok := b.lookupCommand("channel", "uptime"); !ok {
+		t.Error("default commands not registered")
+	}
+
+	b, err = NewBasicBot(Config{Channels: []string{"one"}, CredentialSource: EnvCredentials{}, UseTLS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Port != DefaultTLSPort {
+		t.Errorf("got port %s with TLS, want %s", b.Port, DefaultTLSPort)
+	}
+}
+
+func TestNewBasicBotInvalid(t *testing.T) {
+	for _, cfg := range []Config{
+		{PrivatePath: "private.json"},
+		{Channel: "channel"},
+	} {
+		if _, err := NewBasicBot(cfg); err == nil {
+			t.Errorf("NewBasicBot(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// number of per-user cooldown entries kept before expired ones are pruned
+const cooldownPruneSize = 1024
+
+// cooldowns tracks when commands were last used, per channel and per user
+type cooldowns struct {
+	mu sync.Mutex
+	// perUser and global are how long a command can't be used again for, by command name
+	perUser map[string]time.Duration
+	global  map[string]time.Duration
+	// lastUsed is keyed by cooldownKey, with an empty user for the global cooldown
+	lastUsed map[cooldownKey]time.Time
+}
+
+type cooldownKey struct {
+	channel, command, user string
+}
+
+// SetCommandCooldown stops each user from running !name again until d has passed since they last
+// ran it. Commands on cooldown are ignored. A d of zero removes the cooldown.
+func (bb *BasicBot) SetCommandCooldown(name string, d time.Duration) {
+	bb.dispatcher().SetCommandCooldown(name, d)
+}
+
+// SetGlobalCommandCooldown stops anyone from running !name in a channel until d has passed since
+// it last ran there, whoever ran it. A d of zero removes the cooldown.
+func (bb *BasicBot) SetGlobalCommandCooldown(name string, d time.Duration) {
+	bb.dispatcher().SetGlobalCommandCooldown(name, d)
+}
+
+func (c *cooldowns) set(durations *map[string]time.Duration, name string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if *durations == nil {
+		*durations = make(map[string]time.Duration)
+	}
+	if d <= 0 {
+		delete(*durations, strings.ToLower(name))
+		return
+	}
+	(*durations)[strings.ToLower(name)] = d
+}
+
+// use reports whether user may run command in channel now, and if so starts its cooldowns
+func (c *cooldowns) use(channel, command, user string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	command = strings.ToLower(command)
+	perUser, global := c.perUser[command], c.global[command]
+	if perUser == 0 && global == 0 {
+		return true
+	}
+
+	userKey := cooldownKey{channel, command, user}
+	globalKey := cooldownKey{channel, command, ""}
+	if last, ok := c.lastUsed[userKey]; ok && now.Sub(last) < perUser {
+		return false
+	}
+	if last, ok := c.lastUsed[globalKey]; ok && now.Sub(last) < global {
+		return false
+	}
+
+	if c.lastUsed == nil {
+		c.lastUsed = make(map[cooldownKey]time.Time)
+	}
+	if len(c.lastUsed) >= cooldownPruneSize {
+		c.prune(now)
+	}
+	if perUser > 0 {
+		c.lastUsed[userKey] = now
+	}
+	if global > 0 {
+		c.lastUsed[globalKey] = now
+	}
+	return true
+}
+
+// prune forgets the uses whose cooldown has passed, so the map only holds users that ran a command
+// recently
+func (c *cooldowns) prune(now time.Time) {
+	for key, last := range c.lastUsed {
+		d := c.perUser[key.command]
+		if key.user == "" {
+			d = c.global[key.command]
+		}
+		if now.Sub(last) >= d {
+			delete(c.lastUsed, key)
+		}
+	}
+}
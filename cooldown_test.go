@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCommandCooldown(t *testing.T) {
+	b := &BasicBot{}
+	b.SetCommandCooldown("Dice", time.Minute)
+	now := time.Now()
+
+	if !b.dispatcher().cooldowns.use("channel", "dice", "alice", now) {
+		t.Error("first use was on cooldown")
+	}
+	if b.dispatcher().cooldowns.use("channel", "dice", "alice", now.Add(time.Second)) {
+		t.Error("second use by the same user wasn't on cooldown")
+	}
+	if !b.dispatcher().cooldowns.use("channel", "dice", "bob", now.Add(time.Second)) {
+		t.Error("another user was on cooldown")
+	}
+	if !b.dispatcher().cooldowns.use("other", "dice", "alice", now.Add(time.Second)) {
+		t.Error("the cooldown applied to another channel")
+	}
+	if !b.dispatcher().cooldowns.use("channel", "dice", "alice", now.Add(time.Minute)) {
+		t.Error("still on cooldown after it passed")
+	}
+	if !b.dispatcher().cooldowns.use("channel", "other", "alice", now) {
+		t.Error("a command without a cooldown was on cooldown")
+	}
+}
+
+func TestGlobalCommandCooldown(t *testing.T) {
+	b := &BasicBot{}
+	b.SetGlobalCommandCooldown("dice", time.Minute)
+	now := time.Now()
+
+	if !b.dispatcher().cooldowns.use("channel", "dice", "alice", now) {
+		t.Error("first use was on cooldown")
+	}
+	if b.dispatcher().cooldowns.use("channel", "dice", "bob", now.Add(time.Second)) {
+		t.Error("another user wasn't on the global cooldown")
+	}
+
+	b.SetGlobalCommandCooldown("dice", 0)
+	if !b.dispatcher().cooldowns.use("channel", "dice", "bob", now.Add(time.Second)) {
+		t.Error("still on cooldown after it was removed")
+	}
+}
+
+func TestCooldownPrune(t *testing.T) {
+	b := &BasicBot{}
+	b.SetCommandCooldown("dice", time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 10*cooldownPruneSize; i++ {
+		b.dispatcher().cooldowns.use("channel", "dice", fmt.Sprint("user", i), now.Add(time.Duration(i)*time.Minute))
+	}
+	if n := len(b.dispatcher().cooldowns.lastUsed); n > cooldownPruneSize {
+		t.Errorf("%d cooldown entries kept, want at most %d", n, cooldownPruneSize)
+	}
+}
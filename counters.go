@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// counters are named numbers, like a death counter
+type counters struct {
+	mu     sync.Mutex
+	values map[string]int
+}
+
+// GetCounter returns the value of the counter name, zero if it was never set
+func (bb *BasicBot) GetCounter(name string) int {
+	bb.counters.mu.Lock()
+	defer bb.counters.mu.Unlock()
+
+	return bb.counters.values[strings.ToLower(name)]
+}
+
+// SetCounter sets the counter name to value. Counters are saved to Store when it's set.
+func (bb *BasicBot) SetCounter(name string, value int) {
+	bb.counters.mu.Lock()
+	if bb.counters.values == nil {
+		bb.counters.values = make(map[string]int)
+	}
+	bb.counters.values[strings.ToLower(name)] = value
+	bb.counters.mu.Unlock()
+
+	bb.saveState()
+}
+
+// IncrementCounter adds delta to the counter name and returns its new value
+func (bb *BasicBot) IncrementCounter(name string, delta int) int {
+	bb.counters.mu.Lock()
+	if bb.counters.values == nil {
+		bb.counters.values = make(map[string]int)
+	}
+	name = strings.ToLower(name)
+	bb.counters.values[name] += delta
+	value := bb.counters.values[name]
+	bb.counters.mu.Unlock()
+
+	bb.saveState()
+	return value
+}
+
+// snapshot returns a copy of the counters
+func (c *counters) snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string]int, len(c.values))
+	for name, value := range c.values {
+		values[name] = value
+	}
+	return values
+}
+
+// AddCounterCommand adds !name in every channel, showing the counter of the same name. Moderators
+// can change it with "!name +1", "!name -2", "!name set 5" or "!name reset".
+func (bb *BasicBot) AddCounterCommand(name string) {
+	bb.RegisterCommand(name, func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		if len(cmd.Args) == 0 {
+			return bb.Say(msg.Channel, fmt.Sprintf("%s: %d", name, bb.GetCounter(name)))
+		}
+		if msg.Permission() < PermModerator {
+			return errors.New("changing counters is restricted to moderators")
+		}
+
+		var value int
+		switch arg := cmd.Args[0]; {
+		case arg == "reset":
+			bb.SetCounter(name, 0)
+		case arg == "set" && len(cmd.Args) > 1:
+			n, err := strconv.Atoi(cmd.Args[1])
+			if err != nil {
+				return fmt.Errorf("usage: %s%s set <number>", bb.commandPrefix(), name)
+			}
+			bb.SetCounter(name, n)
+			value = n
+		case strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-"):
+			delta, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("usage: %s%s +1", bb.commandPrefix(), name)
+			}
+			value = bb.IncrementCounter(name, delta)
+		default:
+			return fmt.Errorf("usage: %s%s [+n|-n|set n|reset]", bb.commandPrefix(), name)
+		}
+		return bb.Say(msg.Channel, fmt.Sprintf("%s: %d", name, value))
+	})
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestIncrementCounterConcurrent(t *testing.T) {
+	b := &BasicBot{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.IncrementCounter("Deaths", 1)
+		}()
+	}
+	wg.Wait()
+
+	if got := b.GetCounter("deaths"); got != 100 {
+		t.Errorf("got %d deaths, want 100", got)
+	}
+}
+
+func TestCounterCommand(t *testing.T) {
+	conn := newFakeConn()
+	store := &JSONFileStore{Path: filepath.Join(t.TempDir(), "state.json")}
+	b := &BasicBot{Store: store, Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+	b.AddCounterCommand("deaths")
+
+	for _, line := range []string{
+		"@badges=moderator/1;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #channel :!deaths +3",
+		"@badges=;mod=0 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!deaths +100",
+		"@badges=moderator/1;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #channel :!deaths -1",
+		"@badges=;mod=0 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!deaths",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatPrivMsg(context.Background(), m, b)
+	}
+	conn.waitFor(t, "PRIVMSG #channel :deaths: 3\r\nPRIVMSG #channel :deaths: 2\r\nPRIVMSG #channel :deaths: 2\r\n")
+
+	restored := &BasicBot{Store: store}
+	if err := restored.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.GetCounter("deaths"); got != 2 {
+		t.Errorf("restored %d deaths, want 2", got)
+	}
+}
+
+func TestCounterCommandUsage(t *testing.T) {
+	logger := &recordLogger{}
+	b := &BasicBot{CommandPrefix: "?", Logger: logger}
+	b.AddCounterCommand("deaths")
+
+	m, err := ParseMessage("@badges=moderator/1;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #channel :?deaths lots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handleChatPrivMsg(context.Background(), m, b)
+	if !logger.contains("usage: ?deaths [+n|-n|set n|reset]") {
+		t.Errorf("usage not given with the command prefix, logged %q", logger.lines)
+	}
+}
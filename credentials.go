@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// Environment variables read by EnvCredentials by default
+const (
+	EnvOAuthToken  = "TWITCH_OAUTH_TOKEN"
+	EnvBotUsername = "TWITCH_BOT_USERNAME"
+)
+
+// Twitch accepts any nick starting with justinfan without a password, as a read-only connection
+const anonymousNickPrefix = "justinfan"
+
+// OAuthCred struct
+type OAuthCred struct {
+	Password string `json:"password,omitempty"`
+	// Username is the login name of the bot's account. When set, it's used as the bot's Name
+	// unless one was configured.
+	Username string `json:"username,omitempty"`
+
+	// RefreshToken, ClientID and ClientSecret allow the bot to get a new token when Twitch
+	// rejects an expired one. See BasicBot.RefreshCredentials.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// CredentialSource loads the credentials the bot logs in with
+type CredentialSource interface {
+	Credentials() (*OAuthCred, error)
+}
+
+// FileCredentials reads credentials from a JSON file like {"password": "oauth:abc123"}
+type FileCredentials struct {
+	Path string
+}
+
+// Credentials reads and parses the file
+func (f FileCredentials) Credentials() (*OAuthCred, error) {
+	// reads from the file
+	credFile, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("FileCredentials: cannot read credentials: %w", err)
+	}
+
+	cred, err := parseCredentials(credFile)
+	if err != nil {
+		return nil, fmt.Errorf("FileCredentials: cannot parse %s: %w", f.Path, err)
+	}
+	return cred, nil
+}
+
+// ReaderCredentials reads credentials in the JSON format of FileCredentials from R, which suits
+// credentials fetched from a secrets manager, or embedded in tests. R is read to the end, so it
+// can only give the credentials once.
+type ReaderCredentials struct {
+	R io.Reader
+}
+
+// Credentials reads and parses R
+func (r ReaderCredentials) Credentials() (*OAuthCred, error) {
+	data, err := io.ReadAll(r.R)
+	if err != nil {
+		return nil, fmt.Errorf("ReaderCredentials: cannot read credentials: %w", err)
+	}
+
+	cred, err := parseCredentials(data)
+	if err != nil {
+		return nil, fmt.Errorf("ReaderCredentials: cannot parse credentials: %w", err)
+	}
+	return cred, nil
+}
+
+// parseCredentials parses credentials in the JSON format of FileCredentials. Blank data is an
+// error, rather than credentials that fail once the bot tries to log in.
+func parseCredentials(data []byte) (*OAuthCred, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("no credentials, expected JSON like {\"password\": \"oauth:abc123\"}")
+	}
+	cred := &OAuthCred{}
+	if err := json.Unmarshal(data, cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// EnvCredentials reads credentials from environment variables, which suits containers that are
+// handed their secrets through the environment
+type EnvCredentials struct {
+	// TokenVar holds the OAuth token. Defaults to EnvOAuthToken.
+	TokenVar string
+	// UsernameVar holds the bot's login name. Defaults to EnvBotUsername.
+	UsernameVar string
+}
+
+// Credentials reads the variables, failing if any of them is unset or empty
+func (e EnvCredentials) Credentials() (*OAuthCred, error) {
+	tokenVar, usernameVar := e.TokenVar, e.UsernameVar
+	if tokenVar == "" {
+		tokenVar = EnvOAuthToken
+	}
+	if usernameVar == "" {
+		usernameVar = EnvBotUsername
+	}
+
+	cred := &OAuthCred{
+		Password: os.Getenv(tokenVar),
+		Username: os.Getenv(usernameVar),
+	}
+
+	var missing []string
+	if cred.Password == "" {
+		missing = append(missing, tokenVar)
+	}
+	if cred.Username == "" {
+		missing = append(missing, usernameVar)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("EnvCredentials: missing environment variables: %s", strings.Join(missing, ", "))
+	}
+	return cred, nil
+}
+
+// ReadCredentials reads the credentials from a path in order to make a connection, or from
+// CredentialSource when it's set
+//
+// Anonymous bots have no credentials to read, and are given a justinfan nick instead.
+func (bb *BasicBot) ReadCredentials() error {
+	if bb.Anonymous {
+		if !strings.HasPrefix(bb.Name, anonymousNickPrefix) {
+			bb.Name = fmt.Sprintf("%s%d", anonymousNickPrefix, 1000+rand.Intn(89000))
+		}
+		return nil
+	}
+
+	cred, err := bb.credentialSource().Credentials()
+	if err != nil {
+		return fmt.Errorf("BasicBot.ReadCredentials: %w", err)
+	}
+	if err := cred.validate(); err != nil {
+		return fmt.Errorf("BasicBot.ReadCredentials: %w", err)
+	}
+
+	bb.credMu.Lock()
+	bb.Credentials = cred
+	bb.credMu.Unlock()
+	if bb.Name == "" {
+		bb.Name = cred.Username
+	}
+	return nil
+}
+
+func (bb *BasicBot) credentialSource() CredentialSource {
+	if bb.CredentialSource == nil {
+		return FileCredentials{Path: bb.PrivatePath}
+	}
+	return bb.CredentialSource
+}
+
+// validate checks the password is usable with the PASS command, adding the "oauth:" prefix
+// Twitch requires if it's missing
+func (c *OAuthCred) validate() error {
+	c.Password = strings.TrimSpace(c.Password)
+	if c.Password == "" || c.Password == "oauth:" {
+		return errors.New("OAuthCred: password is empty, expected an OAuth token like oauth:abc123")
+	}
+	if !strings.HasPrefix(c.Password, "oauth:") {
+		c.Password = "oauth:" + c.Password
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+		wantErr  bool
+	}{
+		{name: "prefixed token", contents: `{"password": "oauth:abc123"}`, want: "oauth:abc123"},
+		{name: "bare token", contents: `{"password": "abc123"}`, want: "oauth:abc123"},
+		{name: "empty password", contents: `{"password": ""}`, wantErr: true},
+		{name: "missing password", contents: `{}`, wantErr: true},
+		{name: "invalid json", contents: `{"password": `, wantErr: true},
+		{name: "empty file", contents: "", wantErr: true},
+		{name: "blank file", contents: " \n\t\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "creds.json")
+			os.WriteFile(path, []byte(tt.contents), 0600)
+
+			b := &BasicBot{PrivatePath: path}
+			err := b.ReadCredentials()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got password %q", b.Credentials.Password)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Credentials.Password != tt.want {
+				t.Errorf("password = %q, want %q", b.Credentials.Password, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCredentialsMissingFile(t *testing.T) {
+	b := &BasicBot{PrivatePath: filepath.Join(t.TempDir(), "missing.json")}
+	if err := b.ReadCredentials(); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv(EnvOAuthToken, "abc123")
+	t.Setenv(EnvBotUsername, "mybot")
+
+	b := &BasicBot{CredentialSource: EnvCredentials{}}
+	if err := b.ReadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Credentials.Password != "oauth:abc123" || b.Name != "mybot" {
+		t.Errorf("password %q, name %q", b.Credentials.Password, b.Name)
+	}
+}
+
+func TestReaderCredentials(t *testing.T) {
+	b := &BasicBot{CredentialSource: ReaderCredentials{R: strings.NewReader(`{"password": "abc123", "username": "mybot"}`)}}
+	if err := b.ReadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Credentials.Password != "oauth:abc123" || b.Name != "mybot" {
+		t.Errorf("password %q, name %q", b.Credentials.Password, b.Name)
+	}
+
+	if _, err := (ReaderCredentials{R: strings.NewReader(`{"password": `)}).Credentials(); err == nil {
+		t.Error("parsed invalid JSON")
+	}
+}
+
+func TestEnvCredentialsMissing(t *testing.T) {
+	t.Setenv(EnvOAuthToken, "")
+	t.Setenv(EnvBotUsername, "")
+
+	_, err := EnvCredentials{}.Credentials()
+	if err == nil || !strings.Contains(err.Error(), EnvOAuthToken) || !strings.Contains(err.Error(), EnvBotUsername) {
+		t.Errorf("error %v should name both variables", err)
+	}
+}
+
+func TestAnonymous(t *testing.T) {
+	b := &BasicBot{Channel: "channel", Name: "bot", Anonymous: true, Logger: NopLogger{}}
+	if err := b.ReadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.Name, "justinfan") {
+		t.Errorf("got nick %q, want a justinfan nick", b.Name)
+	}
+
+	conn := newFakeConn()
+	b.Capabilities = []string{}
+	b.setConn(conn)
+	defer b.Disconnect()
+	b.JoinChannel()
+	conn.waitFor(t, "JOIN #channel\r\n")
+	if strings.Contains(conn.written(), "PASS") {
+		t.Errorf("sent a password anonymously: %q", conn.written())
+	}
+
+	if err := b.Say("channel", "hello"); !errors.Is(err, ErrAnonymous) {
+		t.Errorf("Say returned %v, want ErrAnonymous", err)
+	}
+	if err := b.Whisper("viewer", "hello"); !errors.Is(err, ErrAnonymous) {
+		t.Errorf("Whisper returned %v, want ErrAnonymous", err)
+	}
+}
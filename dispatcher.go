@@ -0,0 +1,200 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dispatcher holds the commands a bot answers, with their cooldowns. Every bot has one of its own
+// unless its Dispatcher field is set, and bots sharing a Dispatcher share its commands, text
+// commands and cooldowns, whichever of them reads the message.
+//
+// Register commands on the Dispatcher itself, or on any of the bots using it. NewBot registers the
+// default commands on the bot's own Dispatcher, so they're lost when it's replaced.
+type Dispatcher struct {
+	// Responder, when set, is the bot that runs the commands read by every bot using the
+	// Dispatcher, so they're answered by it. For example, an anonymous bot can read chat while
+	// another bot, logged in, answers. Defaults to the bot that read the message.
+	Responder *BasicBot
+
+	mu           sync.RWMutex
+	commands     map[string]map[string]registeredCommand // channel -> command -> handler, "" for all channels
+	textCommands map[string]textCommand
+	descriptions map[string]string
+	aliases      map[string]string // alias -> command
+
+	cooldowns cooldowns
+}
+
+// NewDispatcher creates a Dispatcher without any commands, to be shared by setting the Dispatcher
+// field of each bot
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// dispatcher returns the bot's Dispatcher, creating it on first use
+func (bb *BasicBot) dispatcher() *Dispatcher {
+	bb.dispatchMu.Lock()
+	defer bb.dispatchMu.Unlock()
+
+	if bb.Dispatcher == nil {
+		bb.Dispatcher = NewDispatcher()
+	}
+	return bb.Dispatcher
+}
+
+// responder returns the bot that runs commands read by bb
+func (d *Dispatcher) responder(bb *BasicBot) *BasicBot {
+	if d.Responder != nil {
+		return d.Responder
+	}
+	return bb
+}
+
+// RegisterCommand is BasicBot.RegisterCommand for every bot using the Dispatcher
+func (d *Dispatcher) RegisterCommand(name string, handler CommandHandler) {
+	d.RegisterCommandFor(PermEveryone, name, handler)
+}
+
+// RegisterCommandFor is BasicBot.RegisterCommandFor for every bot using the Dispatcher
+func (d *Dispatcher) RegisterCommandFor(permission Permission, name string, handler CommandHandler) {
+	d.registerCommand("", name, registeredCommand{handler, permission})
+}
+
+// RegisterChannelCommand is BasicBot.RegisterChannelCommand for every bot using the Dispatcher
+func (d *Dispatcher) RegisterChannelCommand(channel, name string, handler CommandHandler) {
+	d.RegisterChannelCommandFor(channel, PermEveryone, name, handler)
+}
+
+// RegisterChannelCommandFor is BasicBot.RegisterChannelCommandFor for every bot using the
+// Dispatcher
+func (d *Dispatcher) RegisterChannelCommandFor(channel string, permission Permission, name string, handler CommandHandler) {
+	d.registerCommand(channel, name, registeredCommand{handler, permission})
+}
+
+// SetCommandCooldown is BasicBot.SetCommandCooldown for every bot using the Dispatcher
+func (d *Dispatcher) SetCommandCooldown(name string, dur time.Duration) {
+	d.cooldowns.set(&d.cooldowns.perUser, name, dur)
+}
+
+// SetGlobalCommandCooldown is BasicBot.SetGlobalCommandCooldown for every bot using the
+// Dispatcher
+func (d *Dispatcher) SetGlobalCommandCooldown(name string, dur time.Duration) {
+	d.cooldowns.set(&d.cooldowns.global, name, dur)
+}
+
+// RegisterAlias is BasicBot.RegisterAlias for every bot using the Dispatcher
+func (d *Dispatcher) RegisterAlias(alias, command string) error {
+	alias, command = strings.ToLower(alias), strings.ToLower(command)
+	if alias == "" || command == "" {
+		return fmt.Errorf("Dispatcher.RegisterAlias: alias or command was empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.textCommands[alias]; ok {
+		return fmt.Errorf("Dispatcher.RegisterAlias: !%s is already a command", alias)
+	}
+	for _, commands := range d.commands {
+		if _, ok := commands[alias]; ok {
+			return fmt.Errorf("Dispatcher.RegisterAlias: !%s is already a command", alias)
+		}
+	}
+	for name := command; ; {
+		if name == alias {
+			return fmt.Errorf("Dispatcher.RegisterAlias: !%s -> !%s would be a cycle", alias, command)
+		}
+		next, ok := d.aliases[name]
+		if !ok {
+			break
+		}
+		name = next
+	}
+
+	if d.aliases == nil {
+		d.aliases = make(map[string]string)
+	}
+	d.aliases[alias] = command
+	return nil
+}
+
+// resolve returns the command name is an alias of, or name itself if it isn't one
+func (d *Dispatcher) resolve(name string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.resolveLocked(strings.ToLower(name))
+}
+
+func (d *Dispatcher) resolveLocked(name string) string {
+	for {
+		command, ok := d.aliases[name]
+		if !ok {
+			return name
+		}
+		name = command
+	}
+}
+
+func (d *Dispatcher) registerCommand(channel, name string, cmd registeredCommand) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.commands == nil {
+		d.commands = make(map[string]map[string]registeredCommand)
+	}
+	if d.commands[channel] == nil {
+		d.commands[channel] = make(map[string]registeredCommand)
+	}
+	d.commands[channel][strings.ToLower(name)] = cmd
+}
+
+// lookupCommand finds the command !name sent to channel
+func (d *Dispatcher) lookupCommand(channel, name string) (registeredCommand, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	name = strings.ToLower(name)
+	if cmd, ok := d.commands[channel][name]; ok {
+		return cmd, true
+	}
+	if cmd, ok := d.commands[""][name]; ok {
+		return cmd, true
+	}
+	if text, ok := d.textCommands[name]; ok {
+		return registeredCommand{text.handler(), text.permission}, true
+	}
+	return registeredCommand{}, false
+}
+
+func (d *Dispatcher) setTextCommand(name string, cmd textCommand) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.textCommands == nil {
+		d.textCommands = make(map[string]textCommand)
+	}
+	d.textCommands[strings.ToLower(name)] = cmd
+}
+
+func (d *Dispatcher) removeTextCommand(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.textCommands, strings.ToLower(name))
+}
+
+// savedTextCommands returns the text commands as they're saved to a StateStore
+func (d *Dispatcher) savedTextCommands() map[string]SavedTextCommand {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	saved := make(map[string]SavedTextCommand, len(d.textCommands))
+	for name, cmd := range d.textCommands {
+		saved[name] = SavedTextCommand{cmd.response, cmd.permission}
+	}
+	return saved
+}
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSharedDispatcher(t *testing.T) {
+	speakerConn := newFakeConn()
+	speaker := &BasicBot{Channel: "channel", Name: "speaker", Logger: NopLogger{}}
+	speaker.setConn(speakerConn)
+
+	listenerConn := newFakeConn(":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!hi\r\n")
+	listener := &BasicBot{Channel: "channel", Anonymous: true, Logger: NopLogger{}}
+	listener.setConn(listenerConn)
+
+	d := NewDispatcher()
+	d.Responder = speaker
+	speaker.Dispatcher = d
+	listener.Dispatcher = d
+	// registered on one bot, seen by both
+	speaker.RegisterCommand("hi", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		return bb.Say(msg.Channel, "hello @"+msg.User)
+	})
+
+	speakerDone := make(chan error, 1)
+	go func() { speakerDone <- speaker.HandleChat() }()
+	listenerDone := make(chan error, 1)
+	go func() { listenerDone <- listener.HandleChat() }()
+
+	speakerConn.waitFor(t, "PRIVMSG #channel :hello @viewer\r\n")
+	listener.Disconnect()
+	speaker.Disconnect()
+	<-listenerDone
+	<-speakerDone
+
+	if strings.Contains(listenerConn.written(), "PRIVMSG") {
+		t.Errorf("listener answered, wrote %q", listenerConn.written())
+	}
+	if _, ok := listener.lookupCommand("channel", "hi"); !ok {
+		t.Error("command registered on the speaker missing from the listener")
+	}
+}
+
+func TestDispatcherCooldownShared(t *testing.T) {
+	d := NewDispatcher()
+	a := &BasicBot{Dispatcher: d}
+	b := &BasicBot{Dispatcher: d}
+	a.SetGlobalCommandCooldown("hi", time.Minute)
+
+	now := time.Now()
+	if !b.dispatcher().cooldowns.use("channel", "hi", "viewer", now) {
+		t.Fatal("first use was on cooldown")
+	}
+	if a.dispatcher().cooldowns.use("channel", "hi", "other", now) {
+		t.Error("use through another bot wasn't on cooldown")
+	}
+}
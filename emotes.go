@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Emote is an emote used in a chat message
+type Emote struct {
+	// ID identifies the emote, e.g. for https://static-cdn.jtvnw.net/emoticons/v2/<ID>/default/dark/1.0
+	ID string `json:"id"`
+	// Name is the text the emote replaces, e.g. Kappa
+	Name string `json:"name"`
+	// Positions are the first and last character of each place the emote is used in the text,
+	// counting characters rather than bytes, in the order Twitch listed them
+	Positions [][2]int `json:"positions"`
+}
+
+// parseEmotes parses the emotes tag, like "25:0-4,12-16/1902:6-10", naming each emote from the
+// text it was sent with. Positions outside the text are dropped, and emotes left with none.
+func parseEmotes(tag, text string) []Emote {
+	if tag == "" {
+		return nil
+	}
+	runes := []rune(text)
+
+	var emotes []Emote
+	for _, entry := range strings.Split(tag, "/") {
+		i := strings.IndexByte(entry, ':')
+		if i <= 0 {
+			continue
+		}
+		emote := Emote{ID: entry[:i]}
+		for _, span := range strings.Split(entry[i+1:], ",") {
+			first, last, ok := parseSpan(span)
+			if !ok || last >= len(runes) {
+				continue
+			}
+			if emote.Name == "" {
+				emote.Name = string(runes[first : last+1])
+			}
+			emote.Positions = append(emote.Positions, [2]int{first, last})
+		}
+		if len(emote.Positions) > 0 {
+			emotes = append(emotes, emote)
+		}
+	}
+	return emotes
+}
+
+// parseSpan parses a "first-last" position of the emotes tag
+func parseSpan(span string) (int, int, bool) {
+	i := strings.IndexByte(span, '-')
+	if i < 0 {
+		return 0, 0, false
+	}
+	first, err1 := strconv.Atoi(span[:i])
+	last, err2 := strconv.Atoi(span[i+1:])
+	if err1 != nil || err2 != nil || first < 0 || last < first {
+		return 0, 0, false
+	}
+	return first, last, true
+}
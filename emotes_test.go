@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEmotes(t *testing.T) {
+	// from Twitch's IRC documentation
+	line := "@badge-info=;badges=turbo/1;color=#0D4200;display-name=ronni;emotes=25:0-4,12-16/1902:6-10;id=b34ccfc7-4977-403a-8a94-33c6bac34fb8;mod=0;room-id=1337;subscriber=0;tmi-sent-ts=1507246572675;turbo=1;user-id=1337;user-type=global_mod :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #ronni :Kappa Keepo Kappa"
+
+	msg, err := ParseMessage(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Emote{
+		{ID: "25", Name: "Kappa", Positions: [][2]int{{0, 4}, {12, 16}}},
+		{ID: "1902", Name: "Keepo", Positions: [][2]int{{6, 10}}},
+	}
+	if !reflect.DeepEqual(msg.Emotes, want) {
+		t.Errorf("Emotes = %+v, want %+v", msg.Emotes, want)
+	}
+
+	tests := []struct {
+		tag, text string
+		want      []Emote
+	}{
+		{"", "no emotes here", nil},
+		// positions count characters, not bytes
+		{"25:6-10", "日本語です Kappa", []Emote{{ID: "25", Name: "Kappa", Positions: [][2]int{{6, 10}}}}},
+		// a position past the end of the text is dropped
+		{"25:0-4,20-24", "Kappa", []Emote{{ID: "25", Name: "Kappa", Positions: [][2]int{{0, 4}}}}},
+		{"25:bad", "Kappa", nil},
+	}
+	for _, tt := range tests {
+		if got := parseEmotes(tt.tag, tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEmotes(%q, %q) = %+v, want %+v", tt.tag, tt.text, got, tt.want)
+		}
+	}
+}
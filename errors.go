@@ -0,0 +1,57 @@
+package bot
+
+import "errors"
+
+// Errors returned by the bot, wrapped with context. Use errors.Is to check for them.
+var (
+	// ErrAuthFailed is returned when Twitch rejects the bot's credentials. It isn't worth
+	// reconnecting after it without new credentials.
+	ErrAuthFailed = errors.New("login authentication failed")
+
+	// ErrDisconnected is returned when the connection to Twitch drops. Reconnecting may fix it.
+	ErrDisconnected = errors.New("disconnected")
+
+	// ErrNotConnected is returned when sending or connecting can't happen because there is no
+	// connection to Twitch.
+	ErrNotConnected = errors.New("not connected")
+
+	// ErrEmptyMessage is returned when asked to send an empty message.
+	ErrEmptyMessage = errors.New("message was empty")
+
+	// ErrRoomRestricted is returned when a channel's chat mode, like subscribers-only, doesn't
+	// allow the bot to talk.
+	ErrRoomRestricted = errors.New("restricted by the channel's chat mode")
+
+	// ErrNotModerator is returned when the bot is asked to moderate a channel it isn't a
+	// moderator of.
+	ErrNotModerator = errors.New("not a moderator")
+
+	// ErrMissingScope is returned when the OAuth token lacks a scope a Helix endpoint needs.
+	ErrMissingScope = errors.New("the OAuth token is missing the scope")
+
+	// ErrMessageTooLong is returned when asked to send a message longer than MaxMessageLength,
+	// which Twitch would drop.
+	ErrMessageTooLong = errors.New("message is too long")
+
+	// ErrRateLimited is reported by Notice.Err when Twitch dropped a message from the bot for
+	// going over the message limit.
+	ErrRateLimited = errors.New("rate limited by Twitch")
+
+	// ErrDuplicateMessage is reported by Notice.Err when Twitch dropped a message from the bot for
+	// repeating the previous one.
+	ErrDuplicateMessage = errors.New("duplicate message")
+
+	// ErrBanned is reported by Notice.Err when Twitch dropped a message because the bot is banned
+	// or timed out in the channel.
+	ErrBanned = errors.New("banned from the channel")
+
+	// ErrInvalidCharacters is returned when asked to send text holding CR, LF or NUL characters,
+	// which could end the IRC line early and inject further commands.
+	ErrInvalidCharacters = errors.New("contains CR, LF or NUL characters")
+
+	// ErrOnCooldown is returned when an action Twitch limits the rate of was used too recently.
+	ErrOnCooldown = errors.New("on cooldown")
+
+	// ErrAnonymous is returned when an anonymous bot is asked to send a message.
+	ErrAnonymous = errors.New("anonymous connections are read-only")
+)
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"time"
+)
+
+// SubEvent is a subscription announced by a USERNOTICE
+type SubEvent struct {
+	// Type is the msg-id of the notice: sub, resub, subgift, submysterygift, anonsubgift,
+	// giftpaidupgrade, primepaidupgrade, etc.
+	Type string
+	// Channel is where the subscription was announced
+	Channel string
+	// User is the login name of the subscriber, or of the gifter for gifts
+	User string
+	// DisplayName is the display name of User
+	DisplayName string
+	// Tier is the subscription tier, 1 to 3. Prime subscriptions are tier 1.
+	Tier int
+	// Prime is set for subscriptions paid with Prime Gaming
+	Prime bool
+	// CumulativeMonths is the total number of months the user has subscribed for
+	CumulativeMonths int
+	// StreakMonths is the number of consecutive months, zero if the user chose not to share it
+	StreakMonths int
+	// Recipient is the login name of whoever received a gifted subscription
+	Recipient string
+	// GiftCount is the number of subscriptions gifted at once by a submysterygift
+	GiftCount int
+	// Text is the message the user shared with their resub, if any
+	Text string
+	// SystemMsg is Twitch's own description of the event
+	SystemMsg string
+}
+
+// RaidEvent is a raid announced by a USERNOTICE
+type RaidEvent struct {
+	// Channel is the channel being raided
+	Channel string
+	// Raider is the login name of the raiding broadcaster
+	Raider string
+	// DisplayName is the display name of Raider
+	DisplayName string
+	// Viewers is the number of viewers that came with the raid
+	Viewers int
+}
+
+// subscription msg-ids of USERNOTICE messages
+var subNoticeTypes = map[string]bool{
+	"sub":                 true,
+	"resub":               true,
+	"subgift":             true,
+	"submysterygift":      true,
+	"anonsubgift":         true,
+	"anonsubmysterygift":  true,
+	"giftpaidupgrade":     true,
+	"anongiftpaidupgrade": true,
+	"primepaidupgrade":    true,
+	"communitypayforward": true,
+	"standardpayforward":  true,
+	"extendsub":           true,
+}
+
+// parseSubEvent builds a SubEvent from a USERNOTICE, returning false if it isn't a subscription
+func parseSubEvent(m *Message) (*SubEvent, bool) {
+	msgID := m.Tags["msg-id"]
+	if !subNoticeTypes[msgID] {
+		return nil, false
+	}
+
+	ev := &SubEvent{
+		Type:             msgID,
+		Channel:          m.Channel,
+		User:             m.Tags["login"],
+		DisplayName:      m.Tags["display-name"],
+		CumulativeMonths: tagInt(m.Tags, "msg-param-cumulative-months"),
+		StreakMonths:     tagInt(m.Tags, "msg-param-streak-months"),
+		Recipient:        m.Tags["msg-param-recipient-user-name"],
+		GiftCount:        tagInt(m.Tags, "msg-param-mass-gift-count"),
+		Text:             m.Text,
+		SystemMsg:        m.Tags["system-msg"],
+	}
+
+	switch plan := m.Tags["msg-param-sub-plan"]; plan {
+	case "Prime":
+		ev.Tier, ev.Prime = 1, true
+	case "1000", "2000", "3000":
+		ev.Tier = tagInt(m.Tags, "msg-param-sub-plan") / 1000
+	}
+	return ev, true
+}
+
+// parseRaidEvent builds a RaidEvent from a USERNOTICE, returning false if it isn't a raid
+func parseRaidEvent(m *Message) (*RaidEvent, bool) {
+	if m.Tags["msg-id"] != "raid" {
+		return nil, false
+	}
+
+	raider := m.Tags["msg-param-login"]
+	if raider == "" {
+		raider = m.Tags["login"]
+	}
+	return &RaidEvent{
+		Channel:     m.Channel,
+		Raider:      raider,
+		DisplayName: m.Tags["msg-param-displayName"],
+		Viewers:     tagInt(m.Tags, "msg-param-viewerCount"),
+	}, true
+}
+
+func handleUserNotice(m *Message, bb *BasicBot) {
+	if ev, ok := parseSubEvent(m); ok {
+		bb.logger().Infof("#%s %s", m.Channel, ev.SystemMsg)
+		if bb.OnSubscription != nil {
+			bb.OnSubscription(ev)
+		}
+		return
+	}
+	if ev, ok := parseRaidEvent(m); ok {
+		bb.logger().Infof("#%s raided by %s with %d viewers", m.Channel, ev.Raider, ev.Viewers)
+		if bb.OnRaid != nil {
+			bb.OnRaid(ev)
+		}
+		return
+	}
+	bb.logger().Debugf("unhandled USERNOTICE: %s", m.Tags["msg-id"])
+}
+
+// ModerationEvent is a timeout, ban, chat clear or deleted message announced by CLEARCHAT or
+// CLEARMSG
+type ModerationEvent struct {
+	// Type is CLEARCHAT or CLEARMSG
+	Type string
+	// Channel is where the moderation happened
+	Channel string
+	// Target is the login name of the user timed out, banned or whose message was deleted. It is
+	// empty when the whole chat was cleared.
+	Target string
+	// Duration is the length of a timeout, zero for bans and everything else
+	Duration time.Duration
+	// MessageID is the id of the deleted message for CLEARMSG
+	MessageID string
+	// Text is the content of the deleted message for CLEARMSG
+	Text string
+}
+
+// IsBan reports whether the event is a permanent ban
+func (ev *ModerationEvent) IsBan() bool {
+	return ev.Type == "CLEARCHAT" && ev.Target != "" && ev.Duration == 0
+}
+
+// IsTimeout reports whether the event is a timeout
+func (ev *ModerationEvent) IsTimeout() bool {
+	return ev.Type == "CLEARCHAT" && ev.Duration > 0
+}
+
+// IsChatClear reports whether all messages in the channel were cleared
+func (ev *ModerationEvent) IsChatClear() bool {
+	return ev.Type == "CLEARCHAT" && ev.Target == ""
+}
+
+// parseModerationEvent builds a ModerationEvent from a CLEARCHAT or CLEARMSG
+func parseModerationEvent(m *Message) *ModerationEvent {
+	ev := &ModerationEvent{Type: m.Type, Channel: m.Channel}
+	switch m.Type {
+	case "CLEARCHAT":
+		// @ban-duration=350 :tmi.twitch.tv CLEARCHAT #dallas :ronni
+		ev.Target = m.Text
+		ev.Duration = time.Duration(tagInt(m.Tags, "ban-duration")) * time.Second
+	case "CLEARMSG":
+		// @login=ronni;target-msg-id=abc-123 :tmi.twitch.tv CLEARMSG #dallas :HeyGuys
+		ev.Target = m.Tags["login"]
+		ev.MessageID = m.Tags["target-msg-id"]
+		ev.Text = m.Text
+	}
+	return ev
+}
+
+func handleModeration(m *Message, bb *BasicBot) {
+	ev := parseModerationEvent(m)
+	switch {
+	case ev.IsChatClear():
+		bb.logger().Infof("#%s chat was cleared", ev.Channel)
+		if bb.OnChatClear != nil {
+			bb.OnChatClear(ev)
+		}
+	case ev.IsTimeout():
+		bb.logger().Infof("#%s %s was timed out for %s", ev.Channel, ev.Target, ev.Duration)
+		if bb.OnTimeout != nil {
+			bb.OnTimeout(ev)
+		}
+	case ev.IsBan():
+		bb.logger().Infof("#%s %s was banned", ev.Channel, ev.Target)
+		if bb.OnBan != nil {
+			bb.OnBan(ev)
+		}
+	default:
+		bb.logger().Infof("#%s message from %s was deleted: %s", ev.Channel, ev.Target, ev.Text)
+		if bb.OnMessageDeleted != nil {
+			bb.OnMessageDeleted(ev)
+		}
+	}
+}
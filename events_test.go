@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSubEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want *SubEvent
+	}{
+		{
+			name: "sub",
+			line: `@badge-info=subscriber/1;badges=subscriber/0;color=#0000FF;display-name=Ronni;emotes=;flags=;id=db25007f-7a18-43eb-9379-80131e44d633;login=ronni;mod=0;msg-id=sub;msg-param-cumulative-months=1;msg-param-should-share-streak=0;msg-param-streak-months=0;msg-param-sub-plan=1000;msg-param-sub-plan-name=Channel\sSubscription;room-id=1337;subscriber=1;system-msg=ronni\ssubscribed\sat\sTier\s1.;tmi-sent-ts=1507246572675;user-id=1337;user-type= :tmi.twitch.tv USERNOTICE #dallas`,
+			want: &SubEvent{Type: "sub", Channel: "dallas", User: "ronni", DisplayName: "Ronni", Tier: 1, CumulativeMonths: 1, SystemMsg: "ronni subscribed at Tier 1."},
+		},
+		{
+			name: "resub",
+			line: `@badge-info=subscriber/6;badges=staff/1,subscriber/6;color=#008000;display-name=ronni;emotes=;flags=;id=db25007f-7a18-43eb-9379-80131e44d633;login=ronni;mod=0;msg-id=resub;msg-param-cumulative-months=6;msg-param-should-share-streak=1;msg-param-streak-months=2;msg-param-sub-plan=Prime;msg-param-sub-plan-name=Prime;room-id=12345678;subscriber=1;system-msg=ronni\shas\ssubscribed\sfor\s6\smonths!;tmi-sent-ts=1507246572675;turbo=1;user-id=87654321;user-type=staff :tmi.twitch.tv USERNOTICE #dallas :Great stream -- keep it up!`,
+			want: &SubEvent{Type: "resub", Channel: "dallas", User: "ronni", DisplayName: "ronni", Tier: 1, Prime: true, CumulativeMonths: 6, StreakMonths: 2, Text: "Great stream -- keep it up!", SystemMsg: "ronni has subscribed for 6 months!"},
+		},
+		{
+			name: "subgift",
+			line: `@badge-info=;badges=staff/1,premium/1;color=#0000FF;display-name=TWW2;emotes=;flags=;id=e9176cd8-5e22-4684-ad40-ce53c2561c5e;login=tww2;mod=0;msg-id=subgift;msg-param-months=1;msg-param-recipient-display-name=Mr_Woodchuck;msg-param-recipient-id=55554444;msg-param-recipient-user-name=mr_woodchuck;msg-param-sub-plan-name=House\sof\sNyoro~n;msg-param-sub-plan=3000;room-id=19571752;subscriber=0;system-msg=TWW2\sgifted\sa\sTier\s3\ssub\sto\sMr_Woodchuck!;tmi-sent-ts=1521159445153;turbo=0;user-id=87654321;user-type=staff :tmi.twitch.tv USERNOTICE #forstycup`,
+			want: &SubEvent{Type: "subgift", Channel: "forstycup", User: "tww2", DisplayName: "TWW2", Tier: 3, Recipient: "mr_woodchuck", SystemMsg: "TWW2 gifted a Tier 3 sub to Mr_Woodchuck!"},
+		},
+		{
+			name: "submysterygift",
+			line: `@badge-info=;badges=;color=;display-name=Gifter;emotes=;flags=;id=1;login=gifter;mod=0;msg-id=submysterygift;msg-param-mass-gift-count=5;msg-param-sub-plan=2000;room-id=1;subscriber=0;system-msg=Gifter\sis\sgifting\s5\sTier\s2\sSubs!;tmi-sent-ts=1;user-id=2;user-type= :tmi.twitch.tv USERNOTICE #dallas`,
+			want: &SubEvent{Type: "submysterygift", Channel: "dallas", User: "gifter", DisplayName: "Gifter", Tier: 2, GiftCount: 5, SystemMsg: "Gifter is gifting 5 Tier 2 Subs!"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseMessage(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := parseSubEvent(msg)
+			if !ok {
+				t.Fatal("not parsed as a subscription")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRaidEvent(t *testing.T) {
+	line := `@badge-info=;badges=turbo/1;color=#9ACD32;display-name=TestChannel;emotes=;flags=;id=3d830f12-795c-447d-af3c-ea05e40fbddb;login=testchannel;mod=0;msg-id=raid;msg-param-displayName=TestChannel;msg-param-login=testchannel;msg-param-viewerCount=15;room-id=33332222;subscriber=0;system-msg=15\sraiders\sfrom\sTestChannel\shave\sjoined\n!;tmi-sent-ts=1507246572675;turbo=1;user-id=123456;user-type= :tmi.twitch.tv USERNOTICE #othertestchannel`
+
+	msg, err := ParseMessage(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := parseSubEvent(msg); ok {
+		t.Error("raid parsed as a subscription")
+	}
+
+	b := &BasicBot{Logger: NopLogger{}}
+	var got *RaidEvent
+	b.OnRaid = func(ev *RaidEvent) { got = ev }
+	handleUserNotice(msg, b)
+
+	want := &RaidEvent{Channel: "othertestchannel", Raider: "testchannel", DisplayName: "TestChannel", Viewers: 15}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseModerationEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    *ModerationEvent
+		timeout bool
+		ban     bool
+		clear   bool
+	}{
+		{
+			name:    "timeout",
+			line:    "@ban-duration=350;room-id=12345678;target-user-id=87654321;tmi-sent-ts=1642719320727 :tmi.twitch.tv CLEARCHAT #dallas :ronni",
+			want:    &ModerationEvent{Type: "CLEARCHAT", Channel: "dallas", Target: "ronni", Duration: 350 * time.Second},
+			timeout: true,
+		},
+		{
+			name: "ban",
+			line: "@room-id=12345678;target-user-id=87654321;tmi-sent-ts=1642715756806 :tmi.twitch.tv CLEARCHAT #dallas :ronni",
+			want: &ModerationEvent{Type: "CLEARCHAT", Channel: "dallas", Target: "ronni"},
+			ban:  true,
+		},
+		{
+			name:  "chat clear",
+			line:  "@room-id=12345678;tmi-sent-ts=1642715695392 :tmi.twitch.tv CLEARCHAT #dallas",
+			want:  &ModerationEvent{Type: "CLEARCHAT", Channel: "dallas"},
+			clear: true,
+		},
+		{
+			name: "deleted message",
+			line: "@login=foo;room-id=;target-msg-id=94e6c7ff-bf98-4faa-af5d-7ad633a158a9;tmi-sent-ts=1642720582342 :tmi.twitch.tv CLEARMSG #bar :what a great day",
+			want: &ModerationEvent{Type: "CLEARMSG", Channel: "bar", Target: "foo", MessageID: "94e6c7ff-bf98-4faa-af5d-7ad633a158a9", Text: "what a great day"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseMessage(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := parseModerationEvent(msg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+			if got.IsTimeout() != tt.timeout || got.IsBan() != tt.ban || got.IsChatClear() != tt.clear {
+				t.Errorf("IsTimeout %v, IsBan %v, IsChatClear %v", got.IsTimeout(), got.IsBan(), got.IsChatClear())
+			}
+		})
+	}
+}
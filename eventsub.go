@@ -0,0 +1,468 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// EventSubURL is the Twitch EventSub WebSocket endpoint
+	EventSubURL = "wss://eventsub.wss.twitch.tv/ws"
+	// HelixURL is the base URL of the Twitch Helix API
+	HelixURL = "https://api.twitch.tv/helix"
+
+	// used until the welcome message tells us the real keepalive timeout
+	defaultKeepaliveTimeout = 10 * time.Second
+	// slack added to the keepalive timeout before the connection is considered dead
+	keepaliveGrace = 5 * time.Second
+)
+
+// EventSubSubscription is a subscription to an EventSub topic, created when a session starts.
+// See https://dev.twitch.tv/docs/eventsub/eventsub-subscription-types for the available types and
+// the condition each one requires.
+type EventSubSubscription struct {
+	Type      string            `json:"type"`
+	Version   string            `json:"version"`
+	Condition map[string]string `json:"condition"`
+}
+
+// EventSubNotification is an event received over EventSub
+type EventSubNotification struct {
+	// MessageID is unique for each notification, and can be used to drop duplicates
+	MessageID string
+	// Timestamp is when Twitch sent the notification
+	Timestamp time.Time
+	// Type and Version are the subscription type and version the event belongs to
+	Type    string
+	Version string
+	// Event is a pointer to one of the typed event structs, e.g. *ChannelFollowEvent, for the
+	// types this package knows about and json.RawMessage for everything else
+	Event interface{}
+}
+
+// ChannelFollowEvent is the event of a channel.follow notification
+type ChannelFollowEvent struct {
+	UserID               string    `json:"user_id"`
+	UserLogin            string    `json:"user_login"`
+	UserName             string    `json:"user_name"`
+	BroadcasterUserID    string    `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string    `json:"broadcaster_user_login"`
+	BroadcasterUserName  string    `json:"broadcaster_user_name"`
+	FollowedAt           time.Time `json:"followed_at"`
+}
+
+// ChannelSubscribeEvent is the event of a channel.subscribe notification
+type ChannelSubscribeEvent struct {
+	UserID               string `json:"user_id"`
+	UserLogin            string `json:"user_login"`
+	UserName             string `json:"user_name"`
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	BroadcasterUserName  string `json:"broadcaster_user_name"`
+	Tier                 string `json:"tier"`
+	IsGift               bool   `json:"is_gift"`
+}
+
+// ChannelCheerEvent is the event of a channel.cheer notification
+type ChannelCheerEvent struct {
+	IsAnonymous          bool   `json:"is_anonymous"`
+	UserID               string `json:"user_id"`
+	UserLogin            string `json:"user_login"`
+	UserName             string `json:"user_name"`
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	BroadcasterUserName  string `json:"broadcaster_user_name"`
+	Message              string `json:"message"`
+	Bits                 int    `json:"bits"`
+}
+
+// ChannelRaidEvent is the event of a channel.raid notification
+type ChannelRaidEvent struct {
+	FromBroadcasterUserID    string `json:"from_broadcaster_user_id"`
+	FromBroadcasterUserLogin string `json:"from_broadcaster_user_login"`
+	FromBroadcasterUserName  string `json:"from_broadcaster_user_name"`
+	ToBroadcasterUserID      string `json:"to_broadcaster_user_id"`
+	ToBroadcasterUserLogin   string `json:"to_broadcaster_user_login"`
+	ToBroadcasterUserName    string `json:"to_broadcaster_user_name"`
+	Viewers                  int    `json:"viewers"`
+}
+
+// ChannelPointsRedemptionEvent is the event of a
+// channel.channel_points_custom_reward_redemption.add notification
+type ChannelPointsRedemptionEvent struct {
+	ID                   string `json:"id"`
+	UserID               string `json:"user_id"`
+	UserLogin            string `json:"user_login"`
+	UserName             string `json:"user_name"`
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	BroadcasterUserName  string `json:"broadcaster_user_name"`
+	UserInput            string `json:"user_input"`
+	Status               string `json:"status"`
+	Reward               struct {
+		ID     string `json:"id"`
+		Title  string `json:"title"`
+		Cost   int    `json:"cost"`
+		Prompt string `json:"prompt"`
+	} `json:"reward"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// StreamOnlineEvent is the event of a stream.online notification
+type StreamOnlineEvent struct {
+	ID                   string    `json:"id"`
+	BroadcasterUserID    string    `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string    `json:"broadcaster_user_login"`
+	BroadcasterUserName  string    `json:"broadcaster_user_name"`
+	Type                 string    `json:"type"`
+	StartedAt            time.Time `json:"started_at"`
+}
+
+// StreamOfflineEvent is the event of a stream.offline notification
+type StreamOfflineEvent struct {
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	BroadcasterUserName  string `json:"broadcaster_user_name"`
+}
+
+// eventTypes maps subscription types to the structs their events are decoded into
+var eventTypes = map[string]func() interface{}{
+	"channel.follow":    func() interface{} { return &ChannelFollowEvent{} },
+	"channel.subscribe": func() interface{} { return &ChannelSubscribeEvent{} },
+	"channel.cheer":     func() interface{} { return &ChannelCheerEvent{} },
+	"channel.raid":      func() interface{} { return &ChannelRaidEvent{} },
+	"channel.channel_points_custom_reward_redemption.add": func() interface{} { return &ChannelPointsRedemptionEvent{} },
+	"stream.online":  func() interface{} { return &StreamOnlineEvent{} },
+	"stream.offline": func() interface{} { return &StreamOfflineEvent{} },
+}
+
+// EventSubClient receives events from Twitch EventSub over a WebSocket
+type EventSubClient struct {
+	// ClientID is the client id of the application the Token was issued to
+	ClientID string
+	// Token is a user access token, with or without the "oauth:" prefix, that has the scopes the
+	// subscriptions require. Defaults to the bot's current token when run by BasicBot.
+	Token string
+	// Subscriptions are created every time a new session starts
+	Subscriptions []EventSubSubscription
+	// OnNotification is called for every event received
+	OnNotification func(n *EventSubNotification)
+
+	// URL defaults to EventSubURL
+	URL string
+	// HelixURL defaults to HelixURL
+	HelixURL string
+	// HTTPClient is used to create subscriptions. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Logger defaults to the same logger as BasicBot
+	Logger Logger
+
+	running int32
+	// botToken reads the token of the bot running the client when Token is empty, so it follows
+	// the bot's refreshes
+	botToken func() string
+}
+
+// accessToken returns Token, or the bot's token without one
+func (c *EventSubClient) accessToken() string {
+	if c.Token == "" && c.botToken != nil {
+		return c.botToken()
+	}
+	return c.Token
+}
+
+// eventSubMessage is the envelope of every message sent over EventSub
+type eventSubMessage struct {
+	Metadata struct {
+		MessageID           string    `json:"message_id"`
+		MessageType         string    `json:"message_type"`
+		MessageTimestamp    time.Time `json:"message_timestamp"`
+		SubscriptionType    string    `json:"subscription_type"`
+		SubscriptionVersion string    `json:"subscription_version"`
+	} `json:"metadata"`
+	Payload struct {
+		Session *struct {
+			ID                      string `json:"id"`
+			KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+			ReconnectURL            string `json:"reconnect_url"`
+		} `json:"session"`
+		Subscription *struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"subscription"`
+		Event json.RawMessage `json:"event"`
+	} `json:"payload"`
+}
+
+func (c *EventSubClient) logger() Logger {
+	if c.Logger == nil {
+		return defaultLogger
+	}
+	return c.Logger
+}
+
+// Run connects to EventSub and dispatches notifications until ctx is cancelled, reconnecting
+// with backoff whenever the connection drops.
+func (c *EventSubClient) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		return errors.New("EventSubClient.Run: already running")
+	}
+	defer atomic.StoreInt32(&c.running, 0)
+
+	url := c.URL
+	if url == "" {
+		url = EventSubURL
+	}
+	retry := newBackoff(0, 0)
+
+	for {
+		started := time.Now()
+		err := c.session(ctx, url)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if time.Since(started) >= backoffResetThreshold {
+			retry.reset()
+		}
+		delay := retry.next()
+		c.logger().Errorf("%s", err)
+		c.logger().Infof("Reconnecting to EventSub in %s...", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// eventSubConn is a WebSocket connection to EventSub, whose messages are read by a goroutine of
+// its own until it fails with err
+type eventSubConn struct {
+	url      string
+	ws       *websocket.Conn
+	messages chan []byte
+	err      error
+	closed   chan struct{}
+}
+
+// dial connects to url and starts reading its messages
+func (c *EventSubClient) dial(ctx context.Context, url string) (*eventSubConn, error) {
+	config, err := websocket.NewConfig(url, "http://localhost/")
+	if err != nil {
+		return nil, fmt.Errorf("EventSubClient: %w", err)
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("EventSubClient: cannot connect to %s: %w", url, err)
+	}
+	conn := &eventSubConn{url: url, ws: ws, messages: make(chan []byte), closed: make(chan struct{})}
+	go conn.read()
+	return conn, nil
+}
+
+// read passes on the messages received until the connection fails or is closed. err is set
+// before messages is closed.
+func (conn *eventSubConn) read() {
+	defer close(conn.messages)
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn.ws, &data); err != nil {
+			conn.err = fmt.Errorf("EventSubClient: reading from %s: %w", conn.url, err)
+			return
+		}
+		select {
+		case conn.messages <- data:
+		case <-conn.closed:
+			return
+		}
+	}
+}
+
+func (conn *eventSubConn) close() {
+	close(conn.closed)
+	conn.ws.Close()
+}
+
+// session runs an EventSub session until it fails, creating the subscriptions once the server
+// welcomes it. When Twitch moves the session to a new URL, the old connection is read until the
+// new one is welcomed, so the events sent in between aren't lost.
+func (c *EventSubClient) session(ctx context.Context, url string) error {
+	conn, err := c.dial(ctx, url)
+	if err != nil {
+		return err
+	}
+	// next is the connection the session is moving to, until it's welcomed
+	var next *eventSubConn
+	defer func() {
+		conn.close()
+		if next != nil {
+			next.close()
+		}
+	}()
+
+	keepalive := defaultKeepaliveTimeout
+	timeout := time.NewTimer(keepalive + keepaliveGrace)
+	defer timeout.Stop()
+	messages := conn.messages
+	for {
+		var nextMessages chan []byte
+		if next != nil {
+			nextMessages = next.messages
+		}
+		from := conn
+		var data []byte
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout.C:
+			return fmt.Errorf("EventSubClient: nothing received from %s for %s", conn.url, keepalive+keepaliveGrace)
+		case data, ok = <-messages:
+			if !ok && next == nil {
+				return conn.err
+			}
+			if !ok {
+				// Twitch closes the old connection a while after asking to move, waiting is all
+				// that's left to do
+				messages = nil
+				continue
+			}
+		case data, ok = <-nextMessages:
+			if !ok {
+				return next.err
+			}
+			from = next
+		}
+		// any message, keepalives included, proves the connection is alive
+		timeout.Reset(keepalive + keepaliveGrace)
+
+		var msg eventSubMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.logger().Errorf("EventSubClient: malformed message %q: %s", data, err)
+			continue
+		}
+
+		switch msg.Metadata.MessageType {
+		case "session_welcome":
+			session := msg.Payload.Session
+			if session == nil {
+				return errors.New("EventSubClient: welcome message without a session")
+			}
+			if session.KeepaliveTimeoutSeconds > 0 {
+				keepalive = time.Duration(session.KeepaliveTimeoutSeconds) * time.Second
+				timeout.Reset(keepalive + keepaliveGrace)
+			}
+			if from == next {
+				// subscriptions carry over to the new connection, so they mustn't be created again
+				conn.close()
+				conn, next, messages = next, nil, next.messages
+				c.logger().Infof("EventSub session %s moved to %s", session.ID, conn.url)
+				continue
+			}
+			c.logger().Infof("EventSub session %s started", session.ID)
+			for _, sub := range c.Subscriptions {
+				if err := c.subscribe(ctx, session.ID, sub); err != nil {
+					c.logger().Errorf("%s", err)
+				}
+			}
+		case "session_keepalive":
+		case "session_reconnect":
+			if session := msg.Payload.Session; session != nil && session.ReconnectURL != "" && next == nil {
+				c.logger().Infof("EventSub session %s moving to %s", session.ID, session.ReconnectURL)
+				if next, err = c.dial(ctx, session.ReconnectURL); err != nil {
+					return err
+				}
+			}
+		case "notification":
+			c.dispatch(&msg)
+		case "revocation":
+			if sub := msg.Payload.Subscription; sub != nil {
+				c.logger().Errorf("EventSub subscription %s revoked: %s", sub.Type, sub.Status)
+			}
+		default:
+			c.logger().Debugf("unhandled EventSub message type: %s", msg.Metadata.MessageType)
+		}
+	}
+}
+
+func (c *EventSubClient) dispatch(msg *eventSubMessage) {
+	n := &EventSubNotification{
+		MessageID: msg.Metadata.MessageID,
+		Timestamp: msg.Metadata.MessageTimestamp,
+		Type:      msg.Metadata.SubscriptionType,
+		Version:   msg.Metadata.SubscriptionVersion,
+		Event:     msg.Payload.Event,
+	}
+	if newEvent, ok := eventTypes[n.Type]; ok {
+		event := newEvent()
+		if err := json.Unmarshal(msg.Payload.Event, event); err != nil {
+			c.logger().Errorf("EventSubClient: decoding %s event: %s", n.Type, err)
+		} else {
+			n.Event = event
+		}
+	}
+
+	c.logger().Debugf("EventSub %s notification", n.Type)
+	if c.OnNotification != nil {
+		c.OnNotification(n)
+	}
+}
+
+// subscribe creates a subscription delivered to the WebSocket session sessionID
+func (c *EventSubClient) subscribe(ctx context.Context, sessionID string, sub EventSubSubscription) error {
+	body, err := json.Marshal(struct {
+		EventSubSubscription
+		Transport struct {
+			Method    string `json:"method"`
+			SessionID string `json:"session_id"`
+		} `json:"transport"`
+	}{
+		EventSubSubscription: sub,
+		Transport: struct {
+			Method    string `json:"method"`
+			SessionID string `json:"session_id"`
+		}{"websocket", sessionID},
+	})
+	if err != nil {
+		return err
+	}
+
+	base := c.HelixURL
+	if base == "" {
+		base = HelixURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/eventsub/subscriptions", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Client-Id", c.ClientID)
+	req.Header.Set("Authorization", "Bearer "+strings.TrimPrefix(c.accessToken(), "oauth:"))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("EventSubClient: subscribing to %s: %w", sub.Type, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("EventSubClient: subscribing to %s: %s: %s", sub.Type, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestEventSubClient(t *testing.T) {
+	subscribed := make(chan map[string]interface{}, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/helix/eventsub/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Client-Id") != "client" {
+			t.Errorf("bad headers: %v", r.Header)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		subscribed <- body
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.Handle("/ws", websocket.Handler(func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, `{"metadata":{"message_id":"1","message_type":"session_welcome","message_timestamp":"2023-07-19T14:56:51.634234626Z"},"payload":{"session":{"id":"session-id","status":"connected","keepalive_timeout_seconds":10,"reconnect_url":null}}}`)
+		body := <-subscribed
+		if transport, _ := body["transport"].(map[string]interface{}); transport["session_id"] != "session-id" || body["type"] != "channel.follow" {
+			t.Errorf("bad subscription request: %v", body)
+		}
+		websocket.Message.Send(ws, `{"metadata":{"message_id":"2","message_type":"session_keepalive","message_timestamp":"2023-07-19T10:11:12.634234626Z"},"payload":{}}`)
+		websocket.Message.Send(ws, `{"metadata":{"message_id":"3","message_type":"notification","message_timestamp":"2023-07-19T10:11:12.634234626Z","subscription_type":"channel.follow","subscription_version":"2"},"payload":{"subscription":{"type":"channel.follow"},"event":{"user_id":"1234","user_login":"cool_user","user_name":"Cool_User","broadcaster_user_id":"1337","broadcaster_user_login":"cooler_user","broadcaster_user_name":"Cooler_User","followed_at":"2020-07-15T18:16:11.17106713Z"}}}`)
+		// keeps the connection open until the client goes away
+		var discard string
+		websocket.Message.Receive(ws, &discard)
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	notifications := make(chan *EventSubNotification, 1)
+	c := &EventSubClient{
+		ClientID:      "client",
+		Token:         "oauth:token",
+		Subscriptions: []EventSubSubscription{{Type: "channel.follow", Version: "2", Condition: map[string]string{"broadcaster_user_id": "1337"}}},
+		OnNotification: func(n *EventSubNotification) {
+			notifications <- n
+		},
+		URL:      "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+		HelixURL: srv.URL + "/helix",
+		Logger:   NopLogger{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	select {
+	case n := <-notifications:
+		follow, ok := n.Event.(*ChannelFollowEvent)
+		if !ok || follow.UserLogin != "cool_user" || n.Type != "channel.follow" {
+			t.Errorf("got %+v with event %+v", n, n.Event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestEventSubStopsOnShutdown(t *testing.T) {
+	srv := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, `{"metadata":{"message_id":"1","message_type":"session_welcome","message_timestamp":"2023-07-19T14:56:51.634234626Z"},"payload":{"session":{"id":"session-id","keepalive_timeout_seconds":10}}}`)
+		var discard string
+		websocket.Message.Receive(ws, &discard)
+	}))
+	defer srv.Close()
+
+	credPath := filepath.Join(t.TempDir(), "creds.json")
+	os.WriteFile(credPath, []byte(`{"password": "oauth:token"}`), 0600)
+
+	before := runtime.NumGoroutine()
+	b := &BasicBot{
+		// nothing listens on port 1, so the chat connection keeps failing
+		Server:      "127.0.0.1",
+		Port:        "1",
+		PrivatePath: credPath,
+		EventSub:    &EventSubClient{URL: "ws" + strings.TrimPrefix(srv.URL, "http")},
+		Logger:      NopLogger{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.StartContext(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&b.EventSub.running) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("EventSub client never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+	srv.CloseClientConnections()
+
+	deadline = time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&b.EventSub.running) == 1 || runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked after shutdown: %d running, %d before", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEventSubReconnect(t *testing.T) {
+	const welcome = `{"metadata":{"message_id":"%s","message_type":"session_welcome","message_timestamp":"2023-07-19T14:56:51.634234626Z"},"payload":{"session":{"id":"session-id","keepalive_timeout_seconds":10}}}`
+	const follow = `{"metadata":{"message_id":"%s","message_type":"notification","message_timestamp":"2023-07-19T10:11:12.634234626Z","subscription_type":"channel.follow","subscription_version":"2"},"payload":{"subscription":{"type":"channel.follow"},"event":{"user_login":"%s"}}}`
+
+	var subscriptions int32
+	var movedURL string
+	oldSent := make(chan struct{})
+	oldClosed := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/helix/eventsub/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&subscriptions, 1)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.Handle("/ws", websocket.Handler(func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, fmt.Sprintf(welcome, "1"))
+		websocket.Message.Send(ws, fmt.Sprintf(`{"metadata":{"message_id":"2","message_type":"session_reconnect","message_timestamp":"2023-07-19T14:56:51.634234626Z"},"payload":{"session":{"id":"session-id","reconnect_url":"%s"}}}`, movedURL))
+		// sent before the new connection is welcomed
+		websocket.Message.Send(ws, fmt.Sprintf(follow, "3", "before"))
+		close(oldSent)
+		var discard string
+		websocket.Message.Receive(ws, &discard)
+		close(oldClosed)
+	}))
+	mux.Handle("/moved", websocket.Handler(func(ws *websocket.Conn) {
+		<-oldSent
+		websocket.Message.Send(ws, fmt.Sprintf(welcome, "4"))
+		websocket.Message.Send(ws, fmt.Sprintf(follow, "5", "after"))
+		var discard string
+		websocket.Message.Receive(ws, &discard)
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	movedURL = "ws" + strings.TrimPrefix(srv.URL, "http") + "/moved"
+
+	notifications := make(chan *EventSubNotification, 2)
+	c := &EventSubClient{
+		ClientID:       "client",
+		Token:          "oauth:token",
+		Subscriptions:  []EventSubSubscription{{Type: "channel.follow", Version: "2"}},
+		OnNotification: func(n *EventSubNotification) { notifications <- n },
+		URL:            "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+		HelixURL:       srv.URL + "/helix",
+		Logger:         NopLogger{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for _, want := range []string{"before", "after"} {
+		select {
+		case n := <-notifications:
+			if follow, ok := n.Event.(*ChannelFollowEvent); !ok || follow.UserLogin != want {
+				t.Errorf("got event %+v, want %s's follow", n.Event, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s's follow not received", want)
+		}
+	}
+	select {
+	case <-oldClosed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("old connection not closed once the new one was welcomed")
+	}
+	if n := atomic.LoadInt32(&subscriptions); n != 1 {
+		t.Errorf("subscribed %d times, want once", n)
+	}
+}
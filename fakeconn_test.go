@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConn is an in-memory ircConn. Reads return the scripted lines from the server and then block
+// until the connection is closed or the read deadline passes; writes are recorded.
+type fakeConn struct {
+	mu           sync.Mutex
+	in           *strings.Reader
+	out          bytes.Buffer
+	closed       bool
+	readDeadline time.Time
+}
+
+func newFakeConn(lines ...string) *fakeConn {
+	return &fakeConn{in: strings.NewReader(strings.Join(lines, ""))}
+}
+
+func (c *fakeConn) Read(p []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		switch {
+		case c.closed:
+			c.mu.Unlock()
+			return 0, io.EOF
+		case c.in.Len() > 0:
+			defer c.mu.Unlock()
+			return c.in.Read(p)
+		case !c.readDeadline.IsZero() && !time.Now().Before(c.readDeadline):
+			c.mu.Unlock()
+			return 0, os.ErrDeadlineExceeded
+		}
+		c.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *fakeConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return c.out.Write(p)
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readDeadline = t
+	return nil
+}
+
+func (c *fakeConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// the rest of net.Conn, so fakeConn can be returned by a Dialer
+
+func (c *fakeConn) SetDeadline(t time.Time) error { return c.SetReadDeadline(t) }
+func (c *fakeConn) LocalAddr() net.Addr           { return fakeAddr{} }
+func (c *fakeConn) RemoteAddr() net.Addr          { return fakeAddr{} }
+
+type fakeAddr struct{}
+
+func (fakeAddr) Network() string { return "fake" }
+func (fakeAddr) String() string  { return "fake" }
+
+// fakeDialer returns conn, recording the address dialed
+type fakeDialer struct {
+	conn *fakeConn
+	addr string
+}
+
+func (d *fakeDialer) Dial(network, addr string) (net.Conn, error) {
+	d.addr = addr
+	return d.conn, nil
+}
+
+// written returns everything written to the connection so far
+func (c *fakeConn) written() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.out.String()
+}
+
+// waitFor waits until want has been written to c, failing the test if it isn't within a few
+// seconds
+func (c *fakeConn) waitFor(t *testing.T, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(c.written(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q not written, got %q", want, c.written())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandleChatScripted(t *testing.T) {
+	conn := newFakeConn(
+		"PING :tmi.twitch.tv\r\n",
+		":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #owner :!repeat not allowed\r\n",
+		":owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!repeat hello world\r\n",
+	)
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.setConn(conn)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	conn.waitFor(t, "PONG :tmi.twitch.tv\r\n")
+	conn.waitFor(t, "PRIVMSG #owner :hello world\r\n")
+	b.Disconnect()
+	<-result
+
+	if strings.Contains(conn.written(), "not allowed") {
+		t.Errorf("repeated a viewer's command, wrote %q", conn.written())
+	}
+}
+
+func TestConnectDialer(t *testing.T) {
+	conn := newFakeConn("PING :tmi.twitch.tv\r\n")
+	dialer := &fakeDialer{conn: conn}
+	b := &BasicBot{Channel: "channel", Server: "irc.chat.twitch.tv", Port: "6667", Dialer: dialer, Logger: NopLogger{}}
+
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+	if dialer.addr != "irc.chat.twitch.tv:6667" {
+		t.Errorf("dialed %q, want irc.chat.twitch.tv:6667", dialer.addr)
+	}
+
+	go b.HandleChat()
+	conn.waitFor(t, "PONG :tmi.twitch.tv\r\n")
+}
+
+func TestOnRawLine(t *testing.T) {
+	lines := []string{
+		"PING :tmi.twitch.tv",
+		"@badge-info=;color= :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :hi",
+		":tmi.twitch.tv CAP * ACK :twitch.tv/tags",
+		"",
+	}
+	conn := newFakeConn(strings.Join(lines, "\r\n") + "\r\n")
+	b := &BasicBot{Channel: "channel", Name: "bot", Logger: NopLogger{}}
+	b.setConn(conn)
+	var got []string
+	b.OnRawLine = func(line string) { got = append(got, line) }
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+	conn.waitFor(t, "PONG :tmi.twitch.tv\r\n")
+	b.Disconnect()
+	<-result
+
+	if strings.Join(got, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("OnRawLine got %q, want %q", got, lines)
+	}
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// FloodProtection sets when a user is flooding chat. Either limit can be disabled by leaving its
+// count at zero.
+type FloodProtection struct {
+	// Identical is how many identical messages a user may send within IdenticalWindow
+	Identical       int
+	IdenticalWindow time.Duration
+	// Messages is how many messages of any kind a user may send within MessagesWindow
+	Messages       int
+	MessagesWindow time.Duration
+	// Action is taken on the message going over a limit, escalating for repeat offenders like
+	// auto-moderation rules do
+	Action ModAction
+}
+
+// DefaultFloodProtection times out users sending 5 identical messages in 10 seconds, or 10
+// messages in 5 seconds
+var DefaultFloodProtection = FloodProtection{
+	Identical:       5,
+	IdenticalWindow: 10 * time.Second,
+	Messages:        10,
+	MessagesWindow:  5 * time.Second,
+	Action:          ModTimeout,
+}
+
+// number of users tracked before idle ones are pruned
+const floodPruneSize = 1024
+
+// floodDetector keeps the recent messages of every user
+type floodDetector struct {
+	mu    sync.Mutex
+	users map[string][]floodEntry // channel + " " + user -> messages, oldest first
+}
+
+type floodEntry struct {
+	text string
+	at   time.Time
+}
+
+// check records m and reports whether its sender has gone over a limit of p
+func (d *floodDetector) check(p *FloodProtection, m *Message, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	window := p.IdenticalWindow
+	if p.MessagesWindow > window {
+		window = p.MessagesWindow
+	}
+	if d.users == nil {
+		d.users = make(map[string][]floodEntry)
+	}
+	if len(d.users) >= floodPruneSize {
+		d.prune(now, window)
+	}
+
+	key := m.Channel + " " + m.User
+	text := strings.ToLower(strings.TrimSpace(m.Text))
+	entries := append(d.users[key], floodEntry{text, now})
+	// forget what's too old to count towards either limit
+	for len(entries) > 0 && now.Sub(entries[0].at) >= window {
+		entries = entries[1:]
+	}
+	// keep only as many as the limits need
+	if keep := max(p.Identical, p.Messages) + 1; len(entries) > keep {
+		entries = entries[len(entries)-keep:]
+	}
+	d.users[key] = entries
+
+	identical, recent := 0, 0
+	for _, e := range entries {
+		if now.Sub(e.at) < p.IdenticalWindow && e.text == text {
+			identical++
+		}
+		if now.Sub(e.at) < p.MessagesWindow {
+			recent++
+		}
+	}
+	flooding := (p.Identical > 0 && identical > p.Identical) || (p.Messages > 0 && recent > p.Messages)
+	if flooding {
+		// start over, so the same burst isn't punished again
+		delete(d.users, key)
+	}
+	return flooding
+}
+
+// prune forgets the users who haven't sent anything within window. d.mu must be held.
+func (d *floodDetector) prune(now time.Time, window time.Duration) {
+	for key, entries := range d.users {
+		if len(entries) == 0 || now.Sub(entries[len(entries)-1].at) >= window {
+			delete(d.users, key)
+		}
+	}
+}
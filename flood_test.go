@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFloodDetector(t *testing.T) {
+	p := &FloodProtection{Identical: 3, IdenticalWindow: 10 * time.Second, Messages: 5, MessagesWindow: 5 * time.Second}
+	now := time.Now()
+
+	var d floodDetector
+	m := &Message{Channel: "channel", User: "spammer", Text: "buy followers"}
+	for i := 0; i < 3; i++ {
+		if d.check(p, m, now.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("flooding after %d identical messages", i+1)
+		}
+	}
+	if !d.check(p, m, now.Add(3*time.Second)) {
+		t.Error("not flooding after 4 identical messages")
+	}
+
+	d = floodDetector{}
+	for i := 0; i < 5; i++ {
+		if d.check(p, &Message{Channel: "channel", User: "chatty", Text: fmt.Sprint(i)}, now) {
+			t.Fatalf("flooding after %d messages", i+1)
+		}
+	}
+	if !d.check(p, &Message{Channel: "channel", User: "chatty", Text: "one more"}, now) {
+		t.Error("not flooding after 6 messages at once")
+	}
+
+	// spread out, the same messages are fine
+	d = floodDetector{}
+	for i := 0; i < 10; i++ {
+		if d.check(p, m, now.Add(time.Duration(i)*5*time.Second)) {
+			t.Fatalf("flooding with messages 5 seconds apart")
+		}
+	}
+}
+
+func TestFloodDetectorPrune(t *testing.T) {
+	p := &DefaultFloodProtection
+	now := time.Now()
+
+	var d floodDetector
+	for i := 0; i < 3*floodPruneSize; i++ {
+		d.check(p, &Message{Channel: "channel", User: fmt.Sprint("user", i), Text: "hi"}, now.Add(time.Duration(i)*time.Second))
+	}
+	if len(d.users) > floodPruneSize {
+		t.Errorf("tracking %d users, want at most %d", len(d.users), floodPruneSize)
+	}
+}
+
+func TestFloodProtection(t *testing.T) {
+	srv := newModerationServer(t)
+	b := &BasicBot{Name: "bot", Moderator: true, FloodProtection: &FloodProtection{Identical: 2, IdenticalWindow: time.Minute, Action: ModTimeout}, Logger: NopLogger{}}
+	b.Credentials = &OAuthCred{Password: "oauth:token", ClientID: "client"}
+	b.HelixURL = srv.URL
+	b.setConn(newFakeConn())
+	defer b.Disconnect()
+
+	for i := 0; i < 3; i++ {
+		handleChatPrivMsg(context.Background(), &Message{Channel: "channel", User: "spammer", Text: "same thing"}, b)
+	}
+	want := `POST /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot {"data":{"user_id":"id-spammer","duration":600,"reason":"flooding chat"}}`
+	if got := srv.waitMade(t, 1); len(got) != 1 || got[0] != want {
+		t.Errorf("requests %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/ctfrancia/twitchbot
+
+go 1.26.0
+
+require golang.org/x/net v0.59.0
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HelixClient calls the Twitch Helix API with the bot's credentials, which need a ClientID. A
+// token rejected as expired is refreshed once, see BasicBot.RefreshCredentials.
+type HelixClient struct {
+	bb *BasicBot
+}
+
+// HelixError is an error response from the Helix API
+type HelixError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HelixError) Error() string {
+	return fmt.Sprintf("helix: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// HelixUser is a Twitch user
+type HelixUser struct {
+	ID              string    `json:"id"`
+	Login           string    `json:"login"`
+	DisplayName     string    `json:"display_name"`
+	Type            string    `json:"type"`
+	BroadcasterType string    `json:"broadcaster_type"`
+	Description     string    `json:"description"`
+	ProfileImageURL string    `json:"profile_image_url"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// HelixStream is a live stream
+type HelixStream struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	UserLogin   string    `json:"user_login"`
+	UserName    string    `json:"user_name"`
+	GameID      string    `json:"game_id"`
+	GameName    string    `json:"game_name"`
+	Title       string    `json:"title"`
+	ViewerCount int       `json:"viewer_count"`
+	StartedAt   time.Time `json:"started_at"`
+	Language    string    `json:"language"`
+	Tags        []string  `json:"tags"`
+}
+
+// HelixChannel is a channel's information, whether or not it's live
+type HelixChannel struct {
+	BroadcasterID       string   `json:"broadcaster_id"`
+	BroadcasterLogin    string   `json:"broadcaster_login"`
+	BroadcasterName     string   `json:"broadcaster_name"`
+	BroadcasterLanguage string   `json:"broadcaster_language"`
+	GameID              string   `json:"game_id"`
+	GameName            string   `json:"game_name"`
+	Title               string   `json:"title"`
+	Tags                []string `json:"tags"`
+}
+
+// HelixFollower is a user following a channel
+type HelixFollower struct {
+	UserID     string    `json:"user_id"`
+	UserLogin  string    `json:"user_login"`
+	UserName   string    `json:"user_name"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// Helix returns a client for the Helix API sharing the bot's credentials
+func (bb *BasicBot) Helix() *HelixClient {
+	return &HelixClient{bb: bb}
+}
+
+// GetUsers looks up users by login name
+func (h *HelixClient) GetUsers(ctx context.Context, logins ...string) ([]HelixUser, error) {
+	var users []HelixUser
+	err := h.do(ctx, http.MethodGet, "/users", url.Values{"login": logins}, nil, &users)
+	return users, err
+}
+
+// GetStreams returns the streams of the given users that are live
+func (h *HelixClient) GetStreams(ctx context.Context, logins ...string) ([]HelixStream, error) {
+	var streams []HelixStream
+	err := h.do(ctx, http.MethodGet, "/streams", url.Values{"user_login": logins}, nil, &streams)
+	return streams, err
+}
+
+// GetChannel returns the information of the channel of the user with the id broadcasterID
+func (h *HelixClient) GetChannel(ctx context.Context, broadcasterID string) (*HelixChannel, error) {
+	var channels []HelixChannel
+	if err := h.do(ctx, http.MethodGet, "/channels", url.Values{"broadcaster_id": {broadcasterID}}, nil, &channels); err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("HelixClient.GetChannel: no channel with id %s", broadcasterID)
+	}
+	return &channels[0], nil
+}
+
+// GetFollower returns when the user with the id userID followed the channel of broadcasterID, or
+// nil if they don't follow it. The token must belong to a moderator of the channel and have the
+// moderator:read:followers scope.
+func (h *HelixClient) GetFollower(ctx context.Context, broadcasterID, userID string) (*HelixFollower, error) {
+	var followers []HelixFollower
+	query := url.Values{"broadcaster_id": {broadcasterID}, "user_id": {userID}}
+	if err := h.do(ctx, http.MethodGet, "/channels/followers", query, nil, &followers); err != nil {
+		return nil, err
+	}
+	if len(followers) == 0 {
+		return nil, nil
+	}
+	return &followers[0], nil
+}
+
+// do calls the endpoint at path, decoding the data of the response into out when it's not nil.
+// A request rejected with 401 is retried once after refreshing the token, when possible.
+func (h *HelixClient) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	cred := h.bb.credentials()
+	err := h.request(ctx, cred, method, path, query, body, out)
+	var helixErr *HelixError
+	if errors.As(err, &helixErr) && helixErr.StatusCode == http.StatusUnauthorized && cred.canRefresh() {
+		if err := h.bb.refreshCredentials(ctx, cred); err != nil {
+			return err
+		}
+		err = h.request(ctx, h.bb.credentials(), method, path, query, body, out)
+	}
+	return err
+}
+
+func (h *HelixClient) request(ctx context.Context, cred *OAuthCred, method, path string, query url.Values, body, out interface{}) error {
+	if cred == nil || cred.ClientID == "" {
+		return errors.New("HelixClient: the credentials need a client_id to call the Helix API")
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	base := h.bb.HelixURL
+	if base == "" {
+		base = HelixURL
+	}
+	endpoint := base + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Client-Id", cred.ClientID)
+	req.Header.Set("Authorization", "Bearer "+strings.TrimPrefix(cred.Password, "oauth:"))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := h.bb.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("HelixClient: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(respBody))
+		}
+		return fmt.Errorf("HelixClient: %s %s: %w", method, path, &HelixError{resp.StatusCode, apiErr.Message})
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	var data struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return fmt.Errorf("HelixClient: %s %s: %w", method, path, err)
+	}
+	if len(data.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data.Data, out); err != nil {
+		return fmt.Errorf("HelixClient: %s %s: %w", method, path, err)
+	}
+	return nil
+}
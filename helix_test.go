@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHelixGetStreams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/streams" || r.URL.Query().Get("user_login") != "dallas" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Client-Id") != "client" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("bad headers: %v", r.Header)
+		}
+		w.Write([]byte(`{"data":[{"id":"1","user_login":"dallas","game_name":"Elden Ring","title":"first run","viewer_count":42,"started_at":"2021-03-10T15:04:21Z"}],"pagination":{}}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	streams, err := b.Helix().GetStreams(context.Background(), "dallas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streams) != 1 || streams[0].GameName != "Elden Ring" || streams[0].ViewerCount != 42 || streams[0].StartedAt.IsZero() {
+		t.Errorf("got %+v", streams)
+	}
+}
+
+func TestHelixRefreshesExpiredToken(t *testing.T) {
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"new-token","refresh_token":"new-refresh"}`))
+	}))
+	defer tokens.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"141981764","login":"twitchdev","display_name":"TwitchDev"}]}`))
+	}))
+	defer api.Close()
+
+	b := &BasicBot{
+		Credentials:      &OAuthCred{Password: "oauth:old-token", RefreshToken: "refresh", ClientID: "client", ClientSecret: "secret"},
+		CredentialSource: EnvCredentials{},
+		TokenURL:         tokens.URL,
+		HelixURL:         api.URL,
+		Logger:           NopLogger{},
+	}
+	users, err := b.Helix().GetUsers(context.Background(), "twitchdev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != "141981764" {
+		t.Errorf("got %+v", users)
+	}
+}
+
+func TestHelixError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"Bad Request","status":400,"message":"Invalid broadcaster_id"}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL}
+	_, err := b.Helix().GetChannel(context.Background(), "nope")
+	var helixErr *HelixError
+	if !errors.As(err, &helixErr) || helixErr.StatusCode != http.StatusBadRequest || helixErr.Message != "Invalid broadcaster_id" {
+		t.Errorf("got %v, want the Helix error", err)
+	}
+}
+
+func TestHelixRefreshesOnce(t *testing.T) {
+	var refreshes int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&refreshes, 1)
+		r.ParseForm()
+		// refresh tokens can only be used once
+		if r.Form.Get("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`{"access_token":"new-token","refresh_token":"new-refresh"}`))
+	}))
+	defer tokens.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"141981764","login":"twitchdev","display_name":"TwitchDev"}]}`))
+	}))
+	defer api.Close()
+
+	b := &BasicBot{
+		Credentials:      &OAuthCred{Password: "oauth:old-token", RefreshToken: "refresh", ClientID: "client", ClientSecret: "secret"},
+		CredentialSource: EnvCredentials{},
+		TokenURL:         tokens.URL,
+		HelixURL:         api.URL,
+		Logger:           NopLogger{},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Helix().GetUsers(context.Background(), "twitchdev"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Errorf("refreshed %d times, want once", n)
+	}
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CommandInfo describes a command for !help
+type CommandInfo struct {
+	// Name of the command without the prefix
+	Name string
+	// Description is set with SetCommandDescription, and may be empty
+	Description string
+	// Permission is the level users need to run the command
+	Permission Permission
+	// Aliases are the other names the command runs under, sorted
+	Aliases []string
+}
+
+// SetCommandDescription sets what !help <name> says !name does
+func (bb *BasicBot) SetCommandDescription(name, description string) {
+	bb.dispatcher().SetCommandDescription(name, description)
+}
+
+// SetCommandDescription is BasicBot.SetCommandDescription for every bot using the Dispatcher
+func (d *Dispatcher) SetCommandDescription(name, description string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.descriptions == nil {
+		d.descriptions = make(map[string]string)
+	}
+	d.descriptions[strings.ToLower(name)] = description
+}
+
+// Commands returns the commands users with the given permission can run in channel, sorted by
+// name, for a !help of your own
+func (bb *BasicBot) Commands(channel string, permission Permission) []CommandInfo {
+	return bb.dispatcher().Commands(channel, permission)
+}
+
+// Commands is BasicBot.Commands for the bots using the Dispatcher
+func (d *Dispatcher) Commands(channel string, permission Permission) []CommandInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	// in reverse order of precedence, so the command that runs is the one described
+	found := make(map[string]Permission)
+	for name, cmd := range d.textCommands {
+		found[name] = cmd.permission
+	}
+	for name, cmd := range d.commands[""] {
+		found[name] = cmd.permission
+	}
+	if channel != "" {
+		for name, cmd := range d.commands[channel] {
+			found[name] = cmd.permission
+		}
+	}
+
+	var commands []CommandInfo
+	for name, required := range found {
+		if permission < required {
+			continue
+		}
+		var aliases []string
+		for alias := range d.aliases {
+			if d.resolveLocked(alias) == name {
+				aliases = append(aliases, alias)
+			}
+		}
+		sort.Strings(aliases)
+		commands = append(commands, CommandInfo{name, d.descriptions[name], required, aliases})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// cmdHelp lists the commands the user can run, or describes the one given. Register a "help"
+// command of your own to replace it.
+func cmdHelp(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	prefix := bb.commandPrefix()
+	commands := bb.Commands(msg.Channel, msg.Permission())
+
+	if len(cmd.Args) > 0 {
+		asked := strings.ToLower(strings.TrimPrefix(cmd.Args[0], prefix))
+		name := bb.dispatcher().resolve(asked)
+		for _, info := range commands {
+			if info.Name != name {
+				continue
+			}
+			description := info.Description
+			if description == "" {
+				description = "no description"
+			}
+			if len(info.Aliases) > 0 {
+				description += fmt.Sprintf(" (also %s%s)", prefix, strings.Join(info.Aliases, ", "+prefix))
+			}
+			return bb.sayWords(msg.Channel, fmt.Sprintf("%s%s: %s", prefix, name, description))
+		}
+		return bb.Say(msg.Channel, fmt.Sprintf("There's no %s%s command", prefix, asked))
+	}
+
+	names := make([]string, len(commands))
+	for i, info := range commands {
+		names[i] = prefix + info.Name
+	}
+	return bb.sayWords(msg.Channel, "Commands: "+strings.Join(names, ", "))
+}
+
+// sayWords says text in as many messages as it takes to stay under MaxMessageLength, whether or
+// not SplitLongMessages is set
+func (bb *BasicBot) sayWords(channel, text string) error {
+	for _, part := range splitWords(text, MaxMessageLength) {
+		if err := bb.Say(channel, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHelp(t *testing.T) {
+	logger := &recordLogger{}
+	b := NewBot("owner", "bot")
+	b.DryRun = true
+	b.Logger = logger
+	b.RegisterCommandFor(PermModerator, "clear", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error { return nil })
+	b.AddTextCommand("discord", "join us!")
+	b.SetCommandDescription("discord", "links the Discord server")
+	if err := b.RegisterAlias("dc", "discord"); err != nil {
+		t.Fatal(err)
+	}
+
+	viewer := &Message{User: "viewer", Channel: "owner"}
+	mod := &Message{User: "mod", Channel: "owner", Tags: map[string]string{"mod": "1"}}
+	help := func(m *Message, text string) string {
+		t.Helper()
+		logger.mu.Lock()
+		logger.lines = nil
+		logger.mu.Unlock()
+		m.Text = text
+		handleChatPrivMsg(context.Background(), m, b)
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		var said []string
+		for _, line := range logger.lines {
+			if strings.HasPrefix(line, "dry run") {
+				said = append(said, strings.TrimPrefix(line, "dry run, not sending to #owner: "))
+			}
+		}
+		return strings.Join(said, "|")
+	}
+
+	if got := help(viewer, "!help"); got != "Commands: !discord, !help" {
+		t.Errorf("viewer's !help said %q", got)
+	}
+	if got := help(mod, "!help"); got != "Commands: !clear, !discord, !help" {
+		t.Errorf("moderator's !help said %q", got)
+	}
+	if got := help(viewer, "!help !dc"); got != "!discord: links the Discord server (also !dc)" {
+		t.Errorf("!help !dc said %q", got)
+	}
+	if got := help(viewer, "!help clear"); got != "There's no !clear command" {
+		t.Errorf("viewer's !help clear said %q", got)
+	}
+
+	for i := 0; i < 100; i++ {
+		b.AddTextCommand(fmt.Sprintf("command%d", i), "hi")
+	}
+	parts := strings.Split(help(viewer, "!help"), "|")
+	if len(parts) < 2 {
+		t.Fatalf("long !help said in %d message", len(parts))
+	}
+	for _, part := range parts {
+		if n := utf8.RuneCountInString(part); n > MaxMessageLength {
+			t.Errorf("!help said %d characters in one message", n)
+		}
+	}
+}
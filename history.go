@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultHistorySize is how many chat messages are kept by default
+const DefaultHistorySize = 100
+
+// history is a ring buffer of the most recent chat messages
+type history struct {
+	mu       sync.RWMutex
+	messages []Message
+	// next is where the next message goes once messages is full
+	next int
+}
+
+// add keeps m, dropping the oldest message once size are kept
+func (h *history) add(m Message, size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.messages) < size {
+		h.messages = append(h.messages, m)
+		return
+	}
+	h.messages[h.next] = m
+	h.next = (h.next + 1) % len(h.messages)
+}
+
+// recent returns up to n of the latest messages, oldest first
+func (h *history) recent(n int) []Message {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if n > len(h.messages) || n < 0 {
+		n = len(h.messages)
+	}
+	recent := make([]Message, 0, n)
+	for i := len(h.messages) - n; i < len(h.messages); i++ {
+		recent = append(recent, h.messages[(h.next+i)%len(h.messages)])
+	}
+	return recent
+}
+
+// RecentMessages returns up to the n latest chat messages received, oldest first. A negative n
+// returns every message kept, see HistorySize.
+func (bb *BasicBot) RecentMessages(n int) []Message {
+	return bb.history.recent(n)
+}
+
+func (bb *BasicBot) historySize() int {
+	if bb.HistorySize != 0 {
+		return bb.HistorySize
+	}
+	return DefaultHistorySize
+}
+
+// remember adds the chat message m to the history, unless it's excluded
+func (bb *BasicBot) remember(m *Message) {
+	size := bb.historySize()
+	if size < 0 {
+		return
+	}
+	if bb.HistoryExcludeOwn && strings.EqualFold(m.User, bb.Name) {
+		return
+	}
+	if _, ok := bb.parseCommand(m.Text); ok && bb.HistoryExcludeCommands {
+		return
+	}
+	bb.history.add(*m, size)
+}
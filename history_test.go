@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func texts(messages []Message) []string {
+	var texts []string
+	for _, m := range messages {
+		texts = append(texts, m.Text)
+	}
+	return texts
+}
+
+func TestRecentMessages(t *testing.T) {
+	b := &BasicBot{Name: "bot", HistorySize: 3, HistoryExcludeOwn: true, HistoryExcludeCommands: true, Logger: NopLogger{}}
+
+	if got := b.RecentMessages(10); len(got) != 0 {
+		t.Errorf("got %v before any message", texts(got))
+	}
+	for i := 1; i <= 5; i++ {
+		b.remember(&Message{User: "viewer", Text: fmt.Sprint("message ", i)})
+	}
+	b.remember(&Message{User: "Bot", Text: "own message"})
+	b.remember(&Message{User: "viewer", Text: "!dice"})
+
+	if got, want := texts(b.RecentMessages(10)), []string{"message 3", "message 4", "message 5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecentMessages(10) = %v, want %v", got, want)
+	}
+	if got, want := texts(b.RecentMessages(2)), []string{"message 4", "message 5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecentMessages(2) = %v, want %v", got, want)
+	}
+}
+
+func TestRecentMessagesConcurrent(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			b.remember(&Message{User: "viewer", Text: fmt.Sprint(i)})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			b.RecentMessages(10)
+		}
+	}()
+	wg.Wait()
+
+	if got := len(b.RecentMessages(-1)); got != DefaultHistorySize {
+		t.Errorf("kept %d messages, want %d", got, DefaultHistorySize)
+	}
+}
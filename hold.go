@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitCooldown is how long sending chat messages pauses by default after Twitch says
+// the bot is over its message limit, which is counted over 30 seconds
+const DefaultRateLimitCooldown = 30 * time.Second
+
+// maxHeldLines is how many chat lines may wait for the writer. Lines beyond it are dropped, which
+// only happens when chat is paused for long or sent far faster than MsgRate allows.
+const maxHeldLines = 500
+
+// roomWaitTimeout is how long a chat line waits for a channel's chat modes to let the bot talk
+// when WaitForRoomModes is set, before it's dropped
+const roomWaitTimeout = 10 * time.Minute
+
+// heldLines are the chat lines waiting for the writer to send them. They're kept apart from the
+// other outgoing lines so that pausing chat never holds up a PONG.
+type heldLines struct {
+	mu    sync.Mutex
+	lines []outbound
+	// pausedUntil is when chat lines may be written again after Twitch rate limited the bot
+	pausedUntil time.Time
+	// last is the last chat line written to each channel, and sentAt when it was written
+	last   map[string]outbound
+	sentAt map[string]time.Time
+	wake   chan struct{}
+}
+
+// chatSlot says when a chat line to channel may be written, given the last one was written there
+// at last, or false while it can't be written at all
+type chatSlot func(channel string, last time.Time) (time.Time, bool)
+
+// push adds out to the back of the lines, reporting false if there are already maxHeldLines
+func (h *heldLines) push(out outbound) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.lines) >= maxHeldLines {
+		return false
+	}
+	out.queued = time.Now()
+	h.lines = append(h.lines, out)
+	return true
+}
+
+// clear drops the lines, which were meant for a connection that's gone. A pause for Twitch's rate
+// limit carries on, as the limit is the account's rather than the connection's.
+func (h *heldLines) clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lines = nil
+	h.last = nil
+}
+
+// take removes and returns the first line that may be written now. Lines are spaced to no earlier
+// than next, and each line waits for the slot of its channel, behind the earlier lines to the same
+// channel. Otherwise it returns how long until a line may be written, or zero when none can until
+// the lines change. Lines that couldn't be written for roomWaitTimeout are removed and returned as
+// expired.
+func (h *heldLines) take(next time.Time, slot chatSlot) (out outbound, delay time.Duration, ok bool, expired []outbound) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.pausedUntil.After(next) {
+		next = h.pausedUntil
+	}
+	// soonest is when the next line may be written or expire
+	var soonest time.Time
+	later := func(t time.Time) {
+		if soonest.IsZero() || t.Before(soonest) {
+			soonest = t
+		}
+	}
+	waiting := make(map[string]bool)
+	for i := 0; i < len(h.lines); i++ {
+		line := h.lines[i]
+		channel := chatLineChannel(line.line)
+		if waiting[channel] {
+			continue
+		}
+		at, open := slot(channel, h.sentAt[channel])
+		if !open {
+			if expires := line.queued.Add(roomWaitTimeout); expires.After(now) {
+				later(expires)
+				waiting[channel] = true
+				continue
+			}
+			expired = append(expired, line)
+			h.lines = append(h.lines[:i], h.lines[i+1:]...)
+			i--
+			continue
+		}
+		if at.Before(next) {
+			at = next
+		}
+		if at.After(now) {
+			later(at)
+			waiting[channel] = true
+			continue
+		}
+
+		h.lines = append(h.lines[:i], h.lines[i+1:]...)
+		if h.last == nil {
+			h.last = make(map[string]outbound)
+			h.sentAt = make(map[string]time.Time)
+		}
+		h.last[channel] = outbound{line: line.line}
+		h.sentAt[channel] = now
+		return line, 0, true, expired
+	}
+	if !soonest.IsZero() {
+		delay = soonest.Sub(now)
+	}
+	return outbound{}, delay, false, expired
+}
+
+// pause stops chat lines being written for d, and puts the last line written to channel, which
+// Twitch probably dropped, back at the front of the lines
+func (h *heldLines) pause(channel string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pausedUntil = time.Now().Add(d)
+	if out, ok := h.last[channel]; ok {
+		delete(h.last, channel)
+		out.queued = time.Now()
+		h.lines = append([]outbound{out}, h.lines...)
+	}
+	h.signal()
+}
+
+// notify wakes the writer to take another look at the lines, after something take's slots depend
+// on changed
+func (h *heldLines) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.signal()
+}
+
+// wakeup is signalled when the lines change other than by push, so the writer takes another look
+func (h *heldLines) wakeup() <-chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.wake == nil {
+		h.wake = make(chan struct{}, 1)
+	}
+	return h.wake
+}
+
+func (h *heldLines) signal() {
+	if h.wake == nil {
+		h.wake = make(chan struct{}, 1)
+	}
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// isChatLine reports whether line is a PRIVMSG, with or without tags
+func isChatLine(line string) bool {
+	if strings.HasPrefix(line, "@") {
+		_, line = cut(line)
+	}
+	return strings.HasPrefix(line, "PRIVMSG ")
+}
+
+// chatLineChannel returns the channel a PRIVMSG line is sent to, without the "#"
+func chatLineChannel(line string) string {
+	if strings.HasPrefix(line, "@") {
+		_, line = cut(line)
+	}
+	_, line = cut(line)
+	target, _ := cut(line)
+	return strings.TrimPrefix(target, "#")
+}
+
+// rateLimited pauses sending chat messages for RateLimitCooldown after Twitch dropped a message
+// to channel for going over the message limit. The dropped message is sent again afterwards.
+func (bb *BasicBot) rateLimited(channel string) {
+	cooldown := bb.RateLimitCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultRateLimitCooldown
+	}
+	bb.logger().Errorf("rate limited by Twitch, pausing chat messages for %s", cooldown)
+	bb.held.pause(channel, cooldown)
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitNoticePausesSending(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}, RateLimitCooldown: 200 * time.Millisecond}
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	if err := b.Say("channel", "one"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "PRIVMSG #channel :one\r\n")
+
+	m, err := ParseMessage("@msg-id=msg_ratelimit :tmi.twitch.tv NOTICE #channel :Your message was not sent because you are sending messages too quickly.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paused := time.Now()
+	handleNotice(m, b)
+
+	if err := b.Say("channel", "two"); err != nil {
+		t.Fatal(err)
+	}
+	b.send("PONG :tmi.twitch.tv\r\n")
+
+	// other lines still go out while chat is paused
+	conn.waitFor(t, "PONG :tmi.twitch.tv\r\n")
+	if strings.Contains(conn.written(), "two") {
+		t.Fatal("chat message sent while paused")
+	}
+
+	// the dropped message is sent again before the next one
+	conn.waitFor(t, "PRIVMSG #channel :one\r\nPRIVMSG #channel :two\r\n")
+	if elapsed := time.Since(paused); elapsed < b.RateLimitCooldown {
+		t.Errorf("sending resumed after %s, want at least %s", elapsed, b.RateLimitCooldown)
+	}
+}
+
+func TestChatLineChannel(t *testing.T) {
+	for line, want := range map[string]string{
+		"PRIVMSG #dallas :hi\r\n":                         "dallas",
+		"@reply-parent-msg-id=abc PRIVMSG #ronni :hi\r\n": "ronni",
+	} {
+		if !isChatLine(line) {
+			t.Errorf("%q is not a chat line", line)
+		}
+		if got := chatLineChannel(line); got != want {
+			t.Errorf("chatLineChannel(%q) = %q, want %q", line, got, want)
+		}
+	}
+	if isChatLine("PONG :tmi.twitch.tv\r\n") {
+		t.Error("PONG is a chat line")
+	}
+}
+
+func TestHeldLinesCapped(t *testing.T) {
+	var h heldLines
+	for i := 0; i < maxHeldLines; i++ {
+		if !h.push(outbound{line: "PRIVMSG #channel :hi\r\n"}) {
+			t.Fatalf("line %d dropped", i)
+		}
+	}
+	if h.push(outbound{line: "PRIVMSG #channel :one too many\r\n"}) {
+		t.Errorf("held more than %d lines", maxHeldLines)
+	}
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// MessageLogger is a Logger that logs chat messages whole, rather than as the text the bot
+// passes to Infof for them
+type MessageLogger interface {
+	Logger
+	LogMessage(m *Message)
+}
+
+// JSONLogger is a Logger writing a JSON object per line, for log collectors. Chat messages are
+// logged with their fields and tags, e.g.
+//
+//	{"ts":"2023-03-01T23:30:00Z","level":"info","type":"PRIVMSG","user":"viewer","display_name":"Viewer","channel":"channel","text":"hi","tags":{"mod":"0"}}
+//
+// and everything else as {"ts":...,"level":...,"msg":...}. The level is info, error or debug.
+// Set it as the bot's Logger in place of the default StdLogger to log JSON.
+type JSONLogger struct {
+	// Debug enables output from Debugf
+	Debug bool
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLogger creates a JSONLogger writing to w
+func NewJSONLogger(w io.Writer, debug bool) *JSONLogger {
+	return &JSONLogger{Debug: debug, enc: json.NewEncoder(w)}
+}
+
+// jsonLine is a line logged by Infof, Errorf or Debugf
+type jsonLine struct {
+	Time  time.Time `json:"ts"`
+	Level string    `json:"level"`
+	Text  string    `json:"msg"`
+}
+
+// jsonMessage is a line logged by LogMessage
+type jsonMessage struct {
+	Time  time.Time `json:"ts"`
+	Level string    `json:"level"`
+	*Message
+}
+
+// Infof logs informational messages
+func (l *JSONLogger) Infof(format string, v ...interface{}) {
+	l.InfofAt(time.Now(), format, v...)
+}
+
+// InfofAt logs informational messages with the time t
+func (l *JSONLogger) InfofAt(t time.Time, format string, v ...interface{}) {
+	l.encode(jsonLine{t, "info", fmt.Sprintf(format, v...)})
+}
+
+// Errorf logs errors
+func (l *JSONLogger) Errorf(format string, v ...interface{}) {
+	l.encode(jsonLine{time.Now(), "error", fmt.Sprintf(format, v...)})
+}
+
+// Debugf logs verbose output, only when Debug is set
+func (l *JSONLogger) Debugf(format string, v ...interface{}) {
+	if l.Debug {
+		l.encode(jsonLine{time.Now(), "debug", fmt.Sprintf(format, v...)})
+	}
+}
+
+// LogMessage logs the chat message m, at the time it was sent when that's known
+func (l *JSONLogger) LogMessage(m *Message) {
+	t := m.SentAt
+	if t.IsZero() {
+		t = time.Now()
+	}
+	l.encode(jsonMessage{t, "info", m})
+}
+
+func (l *JSONLogger) encode(v interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// there's nowhere to report a failure to log
+	l.enc.Encode(v)
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var out bytes.Buffer
+	b := &BasicBot{Channel: "channel", Logger: NewJSONLogger(&out, false)}
+
+	m, err := ParseMessage("@tmi-sent-ts=1677713400000;mod=0;display-name=Viewer;bits=100 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :cheer100 hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SentAt = m.SentAt.UTC()
+	handleChatPrivMsg(context.Background(), m, b)
+	b.logger().Errorf("something %s", "failed")
+	b.logger().Debugf("not logged")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2: %q", len(lines), lines)
+	}
+	want := `{"ts":"2023-03-01T23:30:00Z","level":"info","type":"PRIVMSG","user":"viewer","display_name":"Viewer","channel":"channel","params":["#channel"],"text":"cheer100 hi","bits":100,"tags":{"bits":"100","display-name":"Viewer","mod":"0","tmi-sent-ts":"1677713400000"}}`
+	if lines[0] != want {
+		t.Errorf("logged message\n%s\nwant\n%s", lines[0], want)
+	}
+	var line struct {
+		Time  time.Time `json:"ts"`
+		Level string    `json:"level"`
+		Text  string    `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Level != "error" || line.Text != "something failed" || line.Time.IsZero() {
+		t.Errorf("logged error %+v", line)
+	}
+}
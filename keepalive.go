@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultPingInterval is how often the bot checks the connection is alive by sending a PING
+	DefaultPingInterval = 2 * time.Minute
+	// DefaultPongTimeout is how long the bot waits for a PONG before reconnecting
+	DefaultPongTimeout = 15 * time.Second
+)
+
+func (bb *BasicBot) pingInterval() time.Duration {
+	if bb.PingInterval == 0 {
+		return DefaultPingInterval
+	}
+	return bb.PingInterval
+}
+
+func (bb *BasicBot) pongTimeout() time.Duration {
+	if bb.PongTimeout <= 0 {
+		return DefaultPongTimeout
+	}
+	return bb.PongTimeout
+}
+
+// LastReceived returns when anything was last received from the server, or the zero time if the
+// bot never received anything
+func (bb *BasicBot) LastReceived() time.Time {
+	return unixNanoTime(atomic.LoadInt64(&bb.lastRecv))
+}
+
+// received records that data arrived from the server
+func (bb *BasicBot) received() {
+	atomic.StoreInt64(&bb.lastRecv, time.Now().UnixNano())
+}
+
+// keepalive PINGs the server every PingInterval and forces a reconnect when neither a PONG nor any
+// other data arrives within PongTimeout. It returns when stop is closed.
+func (bb *BasicBot) keepalive(stop <-chan struct{}) {
+	interval := bb.pingInterval()
+	if interval < 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		// a connection that's busy with chat proves itself alive
+		if time.Since(bb.LastReceived()) < interval {
+			continue
+		}
+
+		sent := time.Now()
+		bb.send("PING :tmi.twitch.tv\r\n")
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(bb.pongTimeout()):
+		}
+
+		if bb.lastPong().Before(sent) && bb.LastReceived().Before(sent) {
+			bb.logger().Errorf("no PONG within %s, last received data at %s. Reconnecting...",
+				bb.pongTimeout(), timeStamp(bb.LastReceived(), bb.TimeZone))
+			// fails the pending read, which takes the usual disconnect and reconnect path
+			bb.conn.SetReadDeadline(time.Now())
+			return
+		}
+	}
+}
+
+func (bb *BasicBot) lastPong() time.Time {
+	return unixNanoTime(atomic.LoadInt64(&bb.lastPongAt))
+}
+
+func unixNanoTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
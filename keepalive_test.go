@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestKeepaliveReconnectsWithoutPong(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{Channel: "channel", PingInterval: 20 * time.Millisecond, PongTimeout: 20 * time.Millisecond, Logger: NopLogger{}}
+	b.setConn(client)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	line, err := bufio.NewReader(server).ReadString('\n')
+	if err != nil || line != "PING :tmi.twitch.tv\r\n" {
+		t.Fatalf("got %q, %v; want a PING", line, err)
+	}
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Error("expected HandleChat to fail without a PONG")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HandleChat kept running without a PONG")
+	}
+}
+
+func TestKeepalivePong(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{Channel: "channel", PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond, Logger: NopLogger{}}
+	b.setConn(client)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	r := bufio.NewReader(server)
+	for i := 0; i < 3; i++ {
+		if line, err := r.ReadString('\n'); err != nil || line != "PING :tmi.twitch.tv\r\n" {
+			t.Fatalf("got %q, %v; want a PING", line, err)
+		}
+		server.Write([]byte(":tmi.twitch.tv PONG tmi.twitch.tv :tmi.twitch.tv\r\n"))
+	}
+
+	select {
+	case err := <-result:
+		t.Fatalf("HandleChat returned %v despite PONGs", err)
+	default:
+	}
+	if b.LastReceived().IsZero() {
+		t.Error("LastReceived not updated")
+	}
+	b.Disconnect()
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Logger is used by the bot for all of its output
+type Logger interface {
+	Infof(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
+}
+
+// TimedLogger is a Logger that can also log what happened at another time than now, such as a chat
+// message sent before the bot read it. The bot uses InfofAt for chat messages when its Logger
+// implements it.
+type TimedLogger interface {
+	Logger
+	InfofAt(t time.Time, format string, v ...interface{})
+}
+
+// StdLogger is a Logger backed by the standard library's log package. Every line is prefixed with
+// a timestamp in TimestampFormat.
+type StdLogger struct {
+	*log.Logger
+	// Debug enables output from Debugf
+	Debug bool
+	// Location is the time zone of the timestamps. Defaults to local time.
+	Location *time.Location
+}
+
+// NewStdLogger creates a StdLogger writing to w
+func NewStdLogger(w io.Writer, debug bool) *StdLogger {
+	return &StdLogger{Logger: log.New(w, "", 0), Debug: debug}
+}
+
+// Infof logs informational messages
+func (l *StdLogger) Infof(format string, v ...interface{}) {
+	l.InfofAt(time.Now(), format, v...)
+}
+
+// InfofAt logs informational messages with the time t
+func (l *StdLogger) InfofAt(t time.Time, format string, v ...interface{}) {
+	l.Printf("[%s] "+format, append([]interface{}{timeStamp(t, l.Location)}, v...)...)
+}
+
+// Errorf logs errors
+func (l *StdLogger) Errorf(format string, v ...interface{}) {
+	l.Printf("[%s] ERROR "+format, append([]interface{}{timeStamp(time.Now(), l.Location)}, v...)...)
+}
+
+// Debugf logs verbose output, only when Debug is set
+func (l *StdLogger) Debugf(format string, v ...interface{}) {
+	if l.Debug {
+		l.Printf("[%s] "+format, append([]interface{}{timeStamp(time.Now(), l.Location)}, v...)...)
+	}
+}
+
+// NopLogger discards everything logged to it, which is useful for silencing the bot in tests
+type NopLogger struct{}
+
+// Infof does nothing
+func (NopLogger) Infof(format string, v ...interface{}) {}
+
+// Errorf does nothing
+func (NopLogger) Errorf(format string, v ...interface{}) {}
+
+// Debugf does nothing
+func (NopLogger) Debugf(format string, v ...interface{}) {}
+
+// defaultLogger keeps the bot's historical behaviour of printing everything to stdout
+var defaultLogger = NewStdLogger(os.Stdout, true)
+
+// logAt logs with the time t, when it's known and the logger can
+func (bb *BasicBot) logAt(t time.Time, format string, v ...interface{}) {
+	if l, ok := bb.logger().(TimedLogger); ok && !t.IsZero() {
+		l.InfofAt(t, format, v...)
+		return
+	}
+	bb.logger().Infof(format, v...)
+}
+
+func (bb *BasicBot) logger() Logger {
+	if bb.Logger != nil {
+		return bb.Logger
+	}
+	if bb.TimeZone != nil {
+		return &StdLogger{Logger: defaultLogger.Logger, Debug: defaultLogger.Debug, Location: bb.TimeZone}
+	}
+	return defaultLogger
+}
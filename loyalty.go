@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPointsInterval is how often points are awarded unless PointsInterval is set
+	DefaultPointsInterval = 5 * time.Minute
+	// DefaultPointsPerInterval is how many points users present get each interval unless
+	// PointsPerInterval is set
+	DefaultPointsPerInterval = 10
+)
+
+// ErrNotEnoughPoints is returned when spending more points than a user has
+var ErrNotEnoughPoints = errors.New("not enough points")
+
+// loyalty holds the points users earn by watching, once EnableLoyalty has been called
+type loyalty struct {
+	mu      sync.Mutex
+	enabled bool
+	points  map[string]int
+	// active are the users who chatted since points were last awarded
+	active map[string]bool
+}
+
+// EnableLoyalty awards points to the users in chat every PointsInterval, PointsPerInterval each
+// plus PointsChatBonus for those who chatted since the last award, while the bot is connected. It
+// sets TrackChatters, as users are known to be present from it, so points are only awarded to the
+// users Chatters lists.
+//
+// It adds !points, for users to see their points or, for moderators, anyone's with !points <user>.
+// Points are saved to Store when it's set.
+func (bb *BasicBot) EnableLoyalty() {
+	bb.TrackChatters = true
+	bb.loyalty.mu.Lock()
+	bb.loyalty.enabled = true
+	bb.loyalty.mu.Unlock()
+	bb.RegisterCommand("points", cmdPoints)
+	bb.SetCommandDescription("points", "how many points you have")
+}
+
+// GetPoints returns the points user has
+func (bb *BasicBot) GetPoints(user string) int {
+	bb.loyalty.mu.Lock()
+	defer bb.loyalty.mu.Unlock()
+
+	return bb.loyalty.points[strings.ToLower(user)]
+}
+
+// AddPoints gives user n more points, or takes them away when n is negative, and returns how many
+// they have now. A user can't have fewer than zero points.
+func (bb *BasicBot) AddPoints(user string, n int) int {
+	bb.loyalty.mu.Lock()
+	points := bb.loyalty.add(strings.ToLower(user), n)
+	bb.loyalty.mu.Unlock()
+
+	bb.saveState()
+	return points
+}
+
+// SpendPoints takes n points from user, returning ErrNotEnoughPoints and taking none when they
+// have fewer
+func (bb *BasicBot) SpendPoints(user string, n int) error {
+	if n < 0 {
+		return errors.New("BasicBot.SpendPoints: cannot spend a negative number of points")
+	}
+	user = strings.ToLower(user)
+
+	bb.loyalty.mu.Lock()
+	if have := bb.loyalty.points[user]; have < n {
+		bb.loyalty.mu.Unlock()
+		return fmt.Errorf("BasicBot.SpendPoints: %s has %d, not %d: %w", user, have, n, ErrNotEnoughPoints)
+	}
+	bb.loyalty.add(user, -n)
+	bb.loyalty.mu.Unlock()
+
+	bb.saveState()
+	return nil
+}
+
+// add adds n to user's points, never going below zero. l.mu must be held.
+func (l *loyalty) add(user string, n int) int {
+	if l.points == nil {
+		l.points = make(map[string]int)
+	}
+	points := l.points[user] + n
+	if points < 0 {
+		points = 0
+	}
+	l.points[user] = points
+	return points
+}
+
+// chatted records that user chatted, for the bonus at the next award
+func (l *loyalty) chatted(user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return
+	}
+	if l.active == nil {
+		l.active = make(map[string]bool)
+	}
+	l.active[strings.ToLower(user)] = true
+}
+
+// snapshot returns a copy of the points
+func (l *loyalty) snapshot() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	points := make(map[string]int, len(l.points))
+	for user, n := range l.points {
+		points[user] = n
+	}
+	return points
+}
+
+// startLoyalty awards points every interval until stop is closed, if EnableLoyalty was called
+func (bb *BasicBot) startLoyalty(stop <-chan struct{}) {
+	bb.loyalty.mu.Lock()
+	enabled := bb.loyalty.enabled
+	bb.loyalty.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	interval := bb.PointsInterval
+	if interval <= 0 {
+		interval = DefaultPointsInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				bb.awardPoints()
+			}
+		}
+	}()
+}
+
+// awardPoints gives the users present in any channel their points for an interval, with the
+// bonus for those who chatted in it
+func (bb *BasicBot) awardPoints() {
+	perInterval := bb.PointsPerInterval
+	if perInterval <= 0 {
+		perInterval = DefaultPointsPerInterval
+	}
+
+	present := make(map[string]bool)
+	for _, channel := range bb.channels() {
+		for _, user := range bb.Chatters(channel) {
+			present[user] = true
+		}
+	}
+	delete(present, strings.ToLower(bb.Name))
+
+	bb.loyalty.mu.Lock()
+	for user := range present {
+		points := perInterval
+		if bb.loyalty.active[user] {
+			points += bb.PointsChatBonus
+		}
+		bb.loyalty.add(user, points)
+	}
+	bb.loyalty.active = nil
+	bb.loyalty.mu.Unlock()
+
+	bb.saveState()
+}
+
+func cmdPoints(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	user := msg.User
+	if len(cmd.Args) > 0 && msg.Permission() >= PermModerator {
+		user = strings.ToLower(strings.TrimPrefix(cmd.Args[0], "@"))
+	}
+	return bb.Say(msg.Channel, fmt.Sprintf("@%s %s has %d points", msg.User, user, bb.GetPoints(user)))
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoyalty(t *testing.T) {
+	store := &JSONFileStore{Path: filepath.Join(t.TempDir(), "state.json")}
+	logger := &recordLogger{}
+	b := &BasicBot{Channel: "channel", Name: "bot", DryRun: true, Store: store, Logger: logger, PointsPerInterval: 10, PointsChatBonus: 5}
+	b.EnableLoyalty()
+
+	for _, line := range []string{
+		":bot.tmi.twitch.tv 353 bot = #channel :bot watcher talker",
+		":talker!talker@talker.tmi.twitch.tv PRIVMSG #channel :hello",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.dispatch(context.Background(), m)
+	}
+	b.awardPoints()
+	b.awardPoints()
+
+	for user, want := range map[string]int{"watcher": 20, "talker": 25, "bot": 0} {
+		if got := b.GetPoints(user); got != want {
+			t.Errorf("%s has %d points, want %d", user, got, want)
+		}
+	}
+
+	if err := b.SpendPoints("Watcher", 21); !errors.Is(err, ErrNotEnoughPoints) {
+		t.Errorf("overspending returned %v, want ErrNotEnoughPoints", err)
+	}
+	if err := b.SpendPoints("watcher", 15); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.AddPoints("watcher", -100); got != 0 {
+		t.Errorf("points went to %d, want 0", got)
+	}
+
+	handleChatPrivMsg(context.Background(), &Message{User: "talker", Channel: "channel", Text: "!points"}, b)
+	if !logger.contains("@talker talker has 25 points") {
+		t.Error("!points didn't say the user's points")
+	}
+
+	restored := &BasicBot{Store: store, Logger: NopLogger{}}
+	for i := 0; i < 2; i++ {
+		if err := restored.loadState(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := restored.GetPoints("talker"); got != 25 {
+		t.Errorf("restored %d points, want 25", got)
+	}
+}
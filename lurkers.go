@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLurkerIdle is how long users must be present without chatting to count as lurkers for
+// !lurkers, unless EnableLurkers is given another duration
+const DefaultLurkerIdle = 30 * time.Minute
+
+// Lurkers returns the users other than the bot who have been in channel for at least idle without
+// chatting in that time, sorted, when TrackChatters is set.
+//
+// Presence comes from the same membership messages as Chatters, so it's late and incomplete in
+// the same ways: users arriving are only seen a while later, users in channels with more than 1000
+// chatters may not be seen at all, and users who leave may still be listed until Twitch says so.
+func (bb *BasicBot) Lurkers(channel string, idle time.Duration) []string {
+	return bb.chatters.lurkers(NormalizeChannel(channel), strings.ToLower(bb.Name), idle, time.Now())
+}
+
+func (c *chatters) lurkers(channel, self string, idle time.Duration, now time.Time) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var users []string
+	for user, p := range c.channels[channel] {
+		if user != self && now.Sub(p.lastActive()) >= idle {
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// EnableLurkers sets TrackChatters and adds !lurkers for moderators, listing the users present for
+// at least idle without chatting. An idle of zero uses DefaultLurkerIdle.
+func (bb *BasicBot) EnableLurkers(idle time.Duration) {
+	if idle <= 0 {
+		idle = DefaultLurkerIdle
+	}
+	bb.TrackChatters = true
+	bb.RegisterCommandFor(PermModerator, "lurkers", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		lurkers := bb.Lurkers(msg.Channel, idle)
+		if len(lurkers) == 0 {
+			return bb.Say(msg.Channel, fmt.Sprintf("Nobody has been lurking for %s", idle))
+		}
+		return bb.sayWords(msg.Channel, fmt.Sprintf("Lurking for %s: %s", idle, strings.Join(lurkers, ", ")))
+	})
+	bb.SetCommandDescription("lurkers", "lists who's here but hasn't chatted lately")
+}
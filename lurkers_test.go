@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLurkers(t *testing.T) {
+	b := &BasicBot{Name: "bot", TrackChatters: true, Logger: NopLogger{}}
+	for _, line := range []string{
+		":bot.tmi.twitch.tv 353 bot = #channel :bot quiet chatty",
+		":late!late@late.tmi.twitch.tv JOIN #channel",
+		":gone!gone@gone.tmi.twitch.tv JOIN #channel",
+		":gone!gone@gone.tmi.twitch.tv PART #channel",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleMembership(m, b)
+	}
+
+	now := time.Now()
+	b.chatters.chatted("channel", "chatty", true, now.Add(50*time.Minute))
+	// chatting without a JOIN seen yet still counts as being present
+	b.chatters.chatted("channel", "unseen", true, now.Add(10*time.Minute))
+
+	got := b.chatters.lurkers("channel", "bot", 30*time.Minute, now.Add(time.Hour))
+	if want := []string{"late", "quiet", "unseen"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lurkers = %v, want %v", got, want)
+	}
+	if got := b.Lurkers("#Channel", time.Hour); len(got) != 0 {
+		t.Errorf("lurkers for an hour = %v, want none yet", got)
+	}
+}
+
+func TestChattersBounded(t *testing.T) {
+	var c chatters
+	now := time.Now()
+	c.add("channel", "first", true, now)
+	for i := 0; i < maxChatters; i++ {
+		c.chatted("channel", fmt.Sprint("user", i), true, now.Add(time.Minute))
+	}
+	if n := len(c.channels["channel"]); n != maxChatters {
+		t.Errorf("tracking %d users, want %d", n, maxChatters)
+	}
+	if _, ok := c.channels["channel"]["first"]; ok {
+		t.Error("the least active user wasn't forgotten")
+	}
+}
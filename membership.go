@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxChatters is how many users are tracked in each channel before the least active are
+// forgotten. Twitch doesn't send PARTs for users leaving large channels, so chatting users would
+// otherwise pile up.
+const maxChatters = 10000
+
+// chatters tracks the users present in each channel, from membership messages and chat
+type chatters struct {
+	mu       sync.Mutex
+	channels map[string]map[string]presence
+}
+
+// presence is when a user was first seen in a channel, and when they last chatted there
+type presence struct {
+	since, chatted time.Time
+}
+
+// Chatters returns the users present in channel, sorted, when TrackChatters is set.
+//
+// They're known from the JOIN and PART messages of the twitch.tv/membership capability, which
+// Twitch sends in batches every few seconds, and stops sending for channels with more than 1000
+// chatters. So the list is late to change, and incomplete in large channels.
+func (bb *BasicBot) Chatters(channel string) []string {
+	bb.chatters.mu.Lock()
+	defer bb.chatters.mu.Unlock()
+
+	channel = NormalizeChannel(channel)
+	users := make([]string, 0, len(bb.chatters.channels[channel]))
+	for user := range bb.chatters.channels[channel] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// handleMembership handles the JOIN and PART messages of users entering and leaving chat, and the
+// 353 messages listing who was there when the bot joined
+func handleMembership(m *Message, bb *BasicBot) {
+	switch m.Type {
+	case "353":
+		for _, user := range strings.Fields(m.Text) {
+			bb.chatters.add(m.Channel, user, bb.TrackChatters, time.Now())
+		}
+	case "JOIN":
+		if strings.EqualFold(m.User, bb.Name) {
+			return
+		}
+		bb.chatters.add(m.Channel, m.User, bb.TrackChatters, time.Now())
+		if bb.OnUserJoin != nil {
+			bb.OnUserJoin(m.User, m.Channel)
+		}
+	case "PART":
+		if strings.EqualFold(m.User, bb.Name) {
+			// the bot left, so it no longer hears who else does
+			bb.chatters.forget(m.Channel)
+			return
+		}
+		bb.chatters.remove(m.Channel, m.User)
+		if bb.OnUserLeave != nil {
+			bb.OnUserLeave(m.User, m.Channel)
+		}
+	}
+}
+
+func (c *chatters) add(channel, user string, track bool, now time.Time) {
+	if !track {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.channels[channel][user]; !ok {
+		c.set(channel, user, presence{since: now})
+	}
+}
+
+// chatted records that user chatted in channel, which also means they're present
+func (c *chatters) chatted(channel, user string, track bool, now time.Time) {
+	if !track {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.channels[channel][user]
+	if !ok {
+		p.since = now
+	}
+	p.chatted = now
+	c.set(channel, user, p)
+}
+
+// set stores p for user, making room for them first if the channel is full. c.mu must be held.
+func (c *chatters) set(channel, user string, p presence) {
+	if c.channels == nil {
+		c.channels = make(map[string]map[string]presence)
+	}
+	users := c.channels[channel]
+	if users == nil {
+		users = make(map[string]presence)
+		c.channels[channel] = users
+	}
+	if _, ok := users[user]; !ok && len(users) >= maxChatters {
+		var idlest string
+		var idlestAt time.Time
+		for name, other := range users {
+			if at := other.lastActive(); idlest == "" || at.Before(idlestAt) {
+				idlest, idlestAt = name, at
+			}
+		}
+		delete(users, idlest)
+	}
+	users[user] = p
+}
+
+// lastActive is when the user last chatted, or arrived if they never did
+func (p presence) lastActive() time.Time {
+	if p.chatted.IsZero() {
+		return p.since
+	}
+	return p.chatted
+}
+
+func (c *chatters) remove(channel, user string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.channels[channel], user)
+}
+
+func (c *chatters) forget(channel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.channels, channel)
+}
+
+// reset forgets every channel's chatters, which Twitch lists again on reconnecting
+func (c *chatters) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.channels = nil
+}
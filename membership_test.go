@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMembership(t *testing.T) {
+	lines := []string{
+		":bot.tmi.twitch.tv 353 bot = #channel :bot ronni",
+		":bot!bot@bot.tmi.twitch.tv JOIN #channel",
+		":viewer!viewer@viewer.tmi.twitch.tv JOIN #channel",
+		":lurker!lurker@lurker.tmi.twitch.tv JOIN #channel",
+		":ronni!ronni@ronni.tmi.twitch.tv PART #channel",
+	}
+
+	var events []string
+	b := &BasicBot{Name: "bot", TrackChatters: true, Logger: NopLogger{}}
+	b.OnUserJoin = func(user, channel string) {
+		events = append(events, "+"+user+"@"+channel)
+	}
+	b.OnUserLeave = func(user, channel string) {
+		events = append(events, "-"+user+"@"+channel)
+	}
+
+	for _, line := range lines {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleMembership(m, b)
+	}
+
+	if got, want := b.Chatters("channel"), []string{"bot", "lurker", "viewer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Chatters = %v, want %v", got, want)
+	}
+	if want := []string{"+viewer@channel", "+lurker@channel", "-ronni@channel"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	// the bot leaving forgets the channel
+	m, _ := ParseMessage(":bot!bot@bot.tmi.twitch.tv PART #channel")
+	handleMembership(m, b)
+	if got := b.Chatters("channel"); len(got) != 0 {
+		t.Errorf("Chatters after parting = %v", got)
+	}
+}
@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Regex for parsing cheermotes, e.g. Cheer100 or uni_cheer50, from the words of a message.
+//
+// First matched group is the cheermote prefix and the second is the number of bits.
+var cheerRegex = regexp.MustCompile(`(?i)^(cheer|uni|uni_cheer|party|showlove|pride|heyguys|frankerz|seemsgood|kreygasm|4head|swiftrage|notlikethis|failfish|vohiyo|pjsalt|mrdestructoid|bday|ripcheer|shamrock|biblethump|doodlecheer|corgo|streamlabs|muxy|holidaycheer|bitboss|anogirl|charitycheer)(\d+)$`)
+
+// Message is a message received from the Twitch IRC server
+type Message struct {
+	// Type is the IRC command of the message, e.g. PRIVMSG or PING
+	Type string `json:"type"`
+	// User is the login name of the sender, empty for messages sent by the server itself. Login
+	// names are always lowercase ASCII.
+	User string `json:"user"`
+	// DisplayName is how the sender's name is shown in chat, which may differ from User in case
+	// or be written in another script entirely, e.g. 日本語. It's taken from the display-name
+	// tag, falling back to User without tags.
+	DisplayName string `json:"display_name"`
+	// Channel is the channel the message was sent to, without the leading "#"
+	Channel string `json:"channel"`
+	// Params are the middle parameters of the message, excluding the trailing text
+	Params []string `json:"params,omitempty"`
+	// Text is the trailing parameter of the message, which for a PRIVMSG is its content
+	Text string `json:"text"`
+	// Bits is the total number of bits cheered with the message
+	Bits int `json:"bits,omitempty"`
+	// IsAction is set for /me messages, whose CTCP ACTION wrapper has been stripped from Text
+	IsAction bool `json:"is_action,omitempty"`
+	// Emotes are the emotes used in Text, from the emotes tag. Nil without any.
+	Emotes []Emote `json:"emotes,omitempty"`
+	// FirstMessage is set when this is the sender's first message ever in the channel
+	FirstMessage bool `json:"first_message,omitempty"`
+	// ReturningChatter is set when the sender has chatted in the channel before, but not often
+	// or recently
+	ReturningChatter bool `json:"returning_chatter,omitempty"`
+	// SubTier is the tier, 1 to 3, of the sender's subscription to the channel, or 0 when they
+	// aren't subscribed. Founders' badges don't show their tier, so they're reported as tier 1.
+	SubTier int `json:"sub_tier,omitempty"`
+	// SubscriberMonths is how many months the sender has been subscribed in total, from the
+	// badge-info tag
+	SubscriberMonths int `json:"subscriber_months,omitempty"`
+	// SentAt is when Twitch received the message, from the tmi-sent-ts tag, which can be well
+	// before the bot reads it when it's behind. Zero without tags. It's in Tags for JSON.
+	SentAt time.Time `json:"-"`
+	// ID is the message's id tag, which Reply takes to answer it in a thread. Empty without tags.
+	ID string `json:"id,omitempty"`
+	// Tags holds the IRCv3 tags sent with the message, with their values unescaped. It is empty
+	// unless the twitch.tv/tags capability was requested.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Raw is the line exactly as received
+	Raw string `json:"-"`
+}
+
+// ParseMessage parses a single line received from the Twitch IRC server.
+//
+// Messages of types the bot doesn't know about are still parsed, so the returned error only
+// reports lines that are not valid IRC, or PRIVMSGs missing their sender or channel.
+func ParseMessage(line string) (*Message, error) {
+	tags, rest := parseTags(line)
+	msg := &Message{ID: tags["id"], Tags: tags, Raw: line}
+	msg.SentAt = msg.Time("tmi-sent-ts")
+
+	if strings.HasPrefix(rest, ":") {
+		var prefix string
+		prefix, rest = cut(rest[1:])
+		// the prefix is :nick!user@host for users and just the host for the server
+		if i := strings.IndexByte(prefix, '!'); i > 0 {
+			msg.User = prefix[:i]
+		}
+	}
+	msg.DisplayName = strings.TrimSpace(tags["display-name"])
+	if msg.DisplayName == "" {
+		msg.DisplayName = msg.User
+	}
+
+	msg.Type, rest = cut(rest)
+	if msg.Type == "" {
+		return nil, fmt.Errorf("ParseMessage: no command in line %q", line)
+	}
+
+	for rest != "" {
+		if strings.HasPrefix(rest, ":") {
+			msg.Text = rest[1:]
+			break
+		}
+		var param string
+		param, rest = cut(rest)
+		msg.Params = append(msg.Params, param)
+	}
+
+	for _, param := range msg.Params {
+		if strings.HasPrefix(param, "#") {
+			msg.Channel = param[1:]
+			break
+		}
+	}
+
+	if msg.Type == "PRIVMSG" {
+		if msg.User == "" || msg.Channel == "" {
+			return nil, fmt.Errorf("ParseMessage: malformed PRIVMSG %q", line)
+		}
+		msg.Text, msg.IsAction = unwrapAction(msg.Text)
+		msg.Bits = parseBits(msg.Tags, msg.Text)
+	}
+	msg.Emotes = parseEmotes(tags["emotes"], msg.Text)
+	msg.FirstMessage = msg.Bool("first-msg")
+	msg.ReturningChatter = msg.Bool("returning-chatter")
+	msg.SubTier, msg.SubscriberMonths = parseSubscription(tags)
+	return msg, nil
+}
+
+// parseSubscription reads the tier and months of the sender's subscription from the badges and
+// badge-info tags. The subscriber badge's version is its month milestone, plus 2000 or 3000 for
+// tier 2 and 3 subscriptions, e.g. subscriber/3012.
+func parseSubscription(tags map[string]string) (tier, months int) {
+	for _, badge := range strings.Split(tags["badges"], ",") {
+		name, version, _ := strings.Cut(badge, "/")
+		switch name {
+		case "subscriber":
+			n, _ := strconv.Atoi(version)
+			tier = 1
+			if n >= 2000 {
+				tier = n / 1000
+			}
+		case "founder":
+			tier = 1
+		}
+	}
+	if tier == 0 && tags["subscriber"] == "1" {
+		tier = 1
+	}
+
+	for _, info := range strings.Split(tags["badge-info"], ",") {
+		name, value, _ := strings.Cut(info, "/")
+		if name == "subscriber" || name == "founder" {
+			months, _ = strconv.Atoi(value)
+		}
+	}
+	return tier, months
+}
+
+// parseBits returns the number of bits cheered in a PRIVMSG.
+//
+// Tagged lines are only trusted for their bits tag, since anyone can type a cheermote without
+// cheering. The cheermotes in the text are summed only for lines without tags.
+func parseBits(tags map[string]string, text string) int {
+	if len(tags) > 0 {
+		bits, _ := strconv.Atoi(tags["bits"])
+		return bits
+	}
+
+	total := 0
+	for _, word := range strings.Fields(text) {
+		if matches := cheerRegex.FindStringSubmatch(word); matches != nil {
+			bits, _ := strconv.Atoi(matches[2])
+			total += bits
+		}
+	}
+	return total
+}
+
+// unwrapAction strips the CTCP delimiters Twitch wraps /me messages in ("\x01ACTION text\x01")
+func unwrapAction(text string) (string, bool) {
+	const prefix = "\x01ACTION "
+	if !strings.HasPrefix(text, prefix) {
+		return text, false
+	}
+	return strings.TrimSuffix(text[len(prefix):], "\x01"), true
+}
+
+// cut splits s at the first space, dropping any further spaces after it
+func cut(s string) (string, string) {
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimLeft(s[i+1:], " ")
+}
+
+// parseTags splits the leading IRCv3 tags section ("@key=value;key2=value2 ") from line.
+//
+// Returns the parsed tags and the remainder of the line. Lines without tags return an empty map
+// and the line unchanged.
+func parseTags(line string) (map[string]string, string) {
+	tags := make(map[string]string)
+	if !strings.HasPrefix(line, "@") {
+		return tags, line
+	}
+
+	raw, rest := cut(line[1:])
+	for _, tag := range strings.Split(raw, ";") {
+		if tag == "" {
+			continue
+		}
+		key, value := tag, ""
+		if i := strings.IndexByte(tag, '='); i >= 0 {
+			key, value = tag[:i], unescapeTagValue(tag[i+1:])
+		}
+		tags[key] = value
+	}
+	return tags, rest
+}
+
+// unescapeTagValue reverses the IRCv3 escaping of tag values
+func unescapeTagValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		// a trailing lone backslash is dropped
+		if i == len(value) {
+			break
+		}
+		switch value[i] {
+		case ':':
+			b.WriteByte(';')
+		case 's':
+			b.WriteByte(' ')
+		case 'r':
+			b.WriteByte('\r')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			// covers "\\" as well as unknown escapes, which drop the backslash
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,235 @@
+package bot
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTags(t *testing.T) {
+	line := `@badge-info=;badges=broadcaster/1;color=#FF0000;display-name=Ronni;emotes=;mod=0;system-msg=hello\sworld\:\sbye\;flag :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #ronni :hi`
+
+	tags, rest := parseTags(line)
+	want := map[string]string{
+		"badge-info":   "",
+		"badges":       "broadcaster/1",
+		"color":        "#FF0000",
+		"display-name": "Ronni",
+		"emotes":       "",
+		"mod":          "0",
+		"system-msg":   "hello world; bye",
+		"flag":         "",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+	if rest != ":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #ronni :hi" {
+		t.Errorf("rest = %q", rest)
+	}
+
+	tags, rest = parseTags("PING :tmi.twitch.tv")
+	if len(tags) != 0 || rest != "PING :tmi.twitch.tv" {
+		t.Errorf("untagged line: tags = %v, rest = %q", tags, rest)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    *Message
+		wantErr bool
+	}{
+		{
+			name: "ping",
+			line: "PING :tmi.twitch.tv",
+			want: &Message{Type: "PING", Text: "tmi.twitch.tv"},
+		},
+		{
+			name: "privmsg",
+			line: ":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :Kappa Keepo Kappa",
+			want: &Message{Type: "PRIVMSG", User: "ronni", DisplayName: "ronni", Channel: "dallas", Params: []string{"#dallas"}, Text: "Kappa Keepo Kappa"},
+		},
+		{
+			name: "tagged privmsg",
+			line: "@badges=;color=;display-name=Ronni :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :hi",
+			want: &Message{Type: "PRIVMSG", User: "ronni", DisplayName: "Ronni", Channel: "dallas", Params: []string{"#dallas"}, Text: "hi",
+				Tags: map[string]string{"badges": "", "color": "", "display-name": "Ronni"}},
+		},
+		{
+			name: "sent time",
+			line: "@tmi-sent-ts=1507246572675 :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :hi",
+			want: &Message{Type: "PRIVMSG", User: "ronni", DisplayName: "ronni", Channel: "dallas", Params: []string{"#dallas"}, Text: "hi",
+				SentAt: time.UnixMilli(1507246572675), Tags: map[string]string{"tmi-sent-ts": "1507246572675"}},
+		},
+		{
+			name: "empty privmsg",
+			line: ":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :",
+			want: &Message{Type: "PRIVMSG", User: "ronni", DisplayName: "ronni", Channel: "dallas", Params: []string{"#dallas"}},
+		},
+		{
+			name: "action",
+			line: ":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :\x01ACTION waves\x01",
+			want: &Message{Type: "PRIVMSG", User: "ronni", DisplayName: "ronni", Channel: "dallas", Params: []string{"#dallas"}, Text: "waves", IsAction: true},
+		},
+		{
+			name: "unknown type",
+			line: ":tmi.twitch.tv 001 ronni :Welcome, GLHF!",
+			want: &Message{Type: "001", Params: []string{"ronni"}, Text: "Welcome, GLHF!"},
+		},
+		{name: "empty line", line: "", wantErr: true},
+		{name: "prefix only", line: ":tmi.twitch.tv", wantErr: true},
+		{name: "privmsg without channel", line: ":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG :hi", wantErr: true},
+		{name: "privmsg without sender", line: ":tmi.twitch.tv PRIVMSG #dallas :hi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMessage(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.want.Raw = tt.line
+			if tt.want.Tags == nil {
+				tt.want.Tags = map[string]string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageAction(t *testing.T) {
+	// captured from Twitch after typing "/me !dance with everyone"
+	line := "@badge-info=;badges=premium/1;color=#8A2BE2;display-name=kittykatz;emotes=;first-msg=0;flags=;id=1c34e1f5-4a2e-4d4f-8c4b-0c1b8b1b7b1e;mod=0;room-id=71092938;subscriber=0;tmi-sent-ts=1651170143547;turbo=0;user-id=123456;user-type= :kittykatz!kittykatz@kittykatz.tmi.twitch.tv PRIVMSG #xqc :\x01ACTION !dance with everyone\x01"
+
+	msg, err := ParseMessage(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !msg.IsAction || msg.Text != "!dance with everyone" {
+		t.Errorf("got IsAction %v, Text %q", msg.IsAction, msg.Text)
+	}
+	if cmd, ok := ParseCommand(msg.Text); !ok || cmd.Name != "dance" {
+		t.Errorf("command not parsed from action text: %+v", cmd)
+	}
+}
+
+func TestParseMessageUnicode(t *testing.T) {
+	line := "@badge-info=;badges=;display-name=小猫咪;id=a1b2;mod=0 :xiaomaomi!xiaomaomi@xiaomaomi.tmi.twitch.tv PRIVMSG #dallas :!点歌\u3000晴天 🎵 周杰伦"
+
+	msg, err := ParseMessage(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.User != "xiaomaomi" || msg.DisplayName != "小猫咪" {
+		t.Errorf("got User %q, DisplayName %q", msg.User, msg.DisplayName)
+	}
+
+	cmd, ok := ParseCommand(msg.Text)
+	if !ok {
+		t.Fatalf("command not parsed from %q", msg.Text)
+	}
+	if cmd.Name != "点歌" || !reflect.DeepEqual(cmd.Args, []string{"晴天", "🎵", "周杰伦"}) {
+		t.Errorf("got %+v", cmd)
+	}
+
+	// without tags the display name is the login name
+	msg, err = ParseMessage(":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :hi 👋")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.DisplayName != "ronni" || msg.Text != "hi 👋" {
+		t.Errorf("got DisplayName %q, Text %q", msg.DisplayName, msg.Text)
+	}
+}
+
+func TestParseBits(t *testing.T) {
+	tests := []struct {
+		line string
+		bits int
+	}{
+		{":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :Cheer100 Party50 great stream", 150},
+		{":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :uni_cheer25 hi cheer5", 30},
+		{":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :cheering100 times", 0},
+		{"@bits=300 :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :Cheer100 Cheer100 Cheer100", 300},
+		{"@badges=;color= :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :Cheer100 lol", 0},
+	}
+
+	for _, tt := range tests {
+		msg, err := ParseMessage(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Bits != tt.bits {
+			t.Errorf("ParseMessage(%q).Bits = %d, want %d", tt.line, msg.Bits, tt.bits)
+		}
+	}
+}
+
+func TestParseFirstMessage(t *testing.T) {
+	tests := []struct {
+		line             string
+		first, returning bool
+	}{
+		{"@badge-info=;badges=;color=;display-name=newbie;emotes=;first-msg=1;flags=;id=5d5f0f8c-4bb5-4bf5-9c4b-1c6e0fe2d4f0;mod=0;returning-chatter=0;room-id=71092938;subscriber=0;tmi-sent-ts=1680000000000;turbo=0;user-id=987654;user-type= :newbie!newbie@newbie.tmi.twitch.tv PRIVMSG #xqc :hi first time here", true, false},
+		{"@badge-info=;badges=;color=#1E90FF;display-name=oldtimer;emotes=;first-msg=0;flags=;id=0e6f3c51-0d5f-4a47-b8a6-7ad8c2e5c4c1;mod=0;returning-chatter=1;room-id=71092938;subscriber=0;tmi-sent-ts=1680000000000;turbo=0;user-id=123123;user-type= :oldtimer!oldtimer@oldtimer.tmi.twitch.tv PRIVMSG #xqc :back again", false, true},
+		{":ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #dallas :no tags", false, false},
+	}
+	for _, tt := range tests {
+		msg, err := ParseMessage(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.FirstMessage != tt.first || msg.ReturningChatter != tt.returning {
+			t.Errorf("%s: FirstMessage %v, ReturningChatter %v", msg.User, msg.FirstMessage, msg.ReturningChatter)
+		}
+	}
+
+	b := NewBot("xqc", "bot")
+	b.Logger = NopLogger{}
+	var welcomed []string
+	b.OnFirstMessage = func(m *Message) { welcomed = append(welcomed, m.User) }
+	for _, tt := range tests[:2] {
+		msg, _ := ParseMessage(tt.line)
+		handleChatPrivMsg(context.Background(), msg, b)
+	}
+	if !reflect.DeepEqual(welcomed, []string{"newbie"}) {
+		t.Errorf("OnFirstMessage called for %v, want [newbie]", welcomed)
+	}
+}
+
+func TestParseSubscription(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		tier, months int
+		subscribed   bool
+	}{
+		{"non-sub", "@badge-info=;badges=premium/1;subscriber=0 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #dallas :hi", 0, 0, false},
+		{"tier 1 streak", "@badge-info=subscriber/14;badges=subscriber/12,premium/1;subscriber=1 :loyal!loyal@loyal.tmi.twitch.tv PRIVMSG #dallas :hi", 1, 14, true},
+		{"tier 3", "@badge-info=subscriber/7;badges=subscriber/3006;subscriber=1 :whale!whale@whale.tmi.twitch.tv PRIVMSG #dallas :hi", 3, 7, true},
+		{"founder", "@badge-info=founder/27;badges=founder/0,glhf-pledge/1;subscriber=0 :first!first@first.tmi.twitch.tv PRIVMSG #dallas :hi", 1, 27, true},
+	}
+	for _, tt := range tests {
+		msg, err := ParseMessage(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.SubTier != tt.tier || msg.SubscriberMonths != tt.months {
+			t.Errorf("%s: SubTier %d, SubscriberMonths %d, want %d and %d", tt.name, msg.SubTier, msg.SubscriberMonths, tt.tier, tt.months)
+		}
+		if got := msg.Permission() >= PermSubscriber; got != tt.subscribed {
+			t.Errorf("%s: subscriber permission %v", tt.name, got)
+		}
+	}
+}
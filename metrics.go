@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// Names of the metrics the bot reports to Metrics
+const (
+	// MetricMessagesReceived counts the lines received from the chat server
+	MetricMessagesReceived = "messages_received"
+	// MetricMessagesSent counts the chat messages sent by Say and Reply
+	MetricMessagesSent = "messages_sent"
+	// MetricCommandsHandled counts the commands run
+	MetricCommandsHandled = "commands_handled"
+	// MetricCommandDuration observes how long each command's handler took
+	MetricCommandDuration = "command_duration"
+	// MetricConnects counts the connections made to the chat server
+	MetricConnects = "connects"
+	// MetricReconnects counts the reconnect attempts made after losing the connection
+	MetricReconnects = "reconnects"
+	// MetricErrors counts failed connections, lost connections, unparseable lines and commands
+	// that returned an error
+	MetricErrors = "errors"
+)
+
+// Metrics receives measurements of what the bot is doing, for monitoring it. Implementations
+// must be safe for concurrent use. See MemoryMetrics.
+type Metrics interface {
+	// Inc adds one to the counter name
+	Inc(name string)
+	// Observe records a duration measured for name, like how long a command took
+	Observe(name string, d time.Duration)
+}
+
+// nopMetrics is used when the bot has no Metrics, so measuring costs next to nothing
+type nopMetrics struct{}
+
+func (nopMetrics) Inc(string)                    {}
+func (nopMetrics) Observe(string, time.Duration) {}
+
+func (bb *BasicBot) metrics() Metrics {
+	if bb.Metrics == nil {
+		return nopMetrics{}
+	}
+	return bb.Metrics
+}
+
+// MemoryMetrics is a Metrics keeping the measurements in memory, to be read with Snapshot. The
+// zero value is ready to use.
+type MemoryMetrics struct {
+	mu        sync.Mutex
+	counters  map[string]int64
+	durations map[string]DurationStats
+}
+
+// DurationStats summarises the durations observed for a metric
+type DurationStats struct {
+	Count int64
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean is the average duration observed, or zero when there are none
+func (s DurationStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// MetricsSnapshot is a copy of the measurements in a MemoryMetrics at one point in time
+type MetricsSnapshot struct {
+	// Counters are the counts by metric name, like MetricMessagesReceived
+	Counters map[string]int64
+	// Durations are the durations observed by metric name, like MetricCommandDuration
+	Durations map[string]DurationStats
+}
+
+// Inc adds one to the counter name
+func (m *MemoryMetrics) Inc(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counters == nil {
+		m.counters = make(map[string]int64)
+	}
+	m.counters[name]++
+}
+
+// Observe records d for name
+func (m *MemoryMetrics) Observe(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.durations == nil {
+		m.durations = make(map[string]DurationStats)
+	}
+	stats := m.durations[name]
+	stats.Count++
+	stats.Total += d
+	if d > stats.Max {
+		stats.Max = d
+	}
+	m.durations[name] = stats
+}
+
+// Snapshot returns a copy of the measurements so far
+func (m *MemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MetricsSnapshot{
+		Counters:  make(map[string]int64, len(m.counters)),
+		Durations: make(map[string]DurationStats, len(m.durations)),
+	}
+	for name, count := range m.counters {
+		snapshot.Counters[name] = count
+	}
+	for name, stats := range m.durations {
+		snapshot.Durations[name] = stats
+	}
+	return snapshot
+}
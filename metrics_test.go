@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	conn := newFakeConn(
+		"PING :tmi.twitch.tv\r\n",
+		":tmi.twitch.tv\r\n",
+		":owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!repeat hello world\r\n",
+	)
+	metrics := &MemoryMetrics{}
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.Metrics = metrics
+	b.setConn(conn)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	conn.waitFor(t, "PRIVMSG #owner :hello world\r\n")
+	b.Disconnect()
+	<-result
+
+	got := metrics.Snapshot()
+	want := map[string]int64{
+		MetricMessagesReceived: 3,
+		MetricCommandsHandled:  1,
+		MetricMessagesSent:     1,
+		// the unparseable line and the read failing after Disconnect
+		MetricErrors: 2,
+	}
+	for name, count := range want {
+		if got.Counters[name] != count {
+			t.Errorf("%s = %d, want %d", name, got.Counters[name], count)
+		}
+	}
+	if stats := got.Durations[MetricCommandDuration]; stats.Count != 1 {
+		t.Errorf("%s observed %d times, want 1", MetricCommandDuration, stats.Count)
+	}
+}
+
+func TestMemoryMetricsSnapshot(t *testing.T) {
+	var m MemoryMetrics
+	m.Observe("latency", time.Second)
+	m.Observe("latency", 3*time.Second)
+
+	snapshot := m.Snapshot()
+	m.Inc("latency")
+	m.Observe("latency", time.Hour)
+
+	stats := snapshot.Durations["latency"]
+	if stats.Count != 2 || stats.Mean() != 2*time.Second || stats.Max != 3*time.Second {
+		t.Errorf("got %+v", stats)
+	}
+	if len(snapshot.Counters) != 0 {
+		t.Errorf("snapshot changed after it was taken: %v", snapshot.Counters)
+	}
+}
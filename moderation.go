@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// longest timeout Twitch allows, two weeks
+const maxTimeoutSeconds = 1209600
+
+// Timeout stops user talking in channel for the given number of seconds, up to two weeks. The
+// reason is optional.
+//
+// The moderation methods call the Helix API as the bot, so they need the bot to be a moderator in
+// channel and return ErrNotModerator otherwise. Timeout, Ban and Unban need the
+// moderator:manage:banned_users scope, and Delete the moderator:manage:chat_messages scope.
+func (bb *BasicBot) Timeout(channel, user string, seconds int, reason string) error {
+	if user == "" {
+		return errors.New("BasicBot.Timeout: user was empty")
+	}
+	if seconds < 1 || seconds > maxTimeoutSeconds {
+		return fmt.Errorf("BasicBot.Timeout: %d seconds is out of range, timeouts last from 1 to %d seconds", seconds, maxTimeoutSeconds)
+	}
+	err := bb.moderate(channel, fmt.Sprintf("timeout %s %d %s", user, seconds, reason), user, func(ctx context.Context, ids moderationIDs) error {
+		return bb.Helix().BanUser(ctx, ids.broadcaster, ids.moderator, ids.user, seconds, reason)
+	})
+	if err != nil {
+		return fmt.Errorf("BasicBot.Timeout: %w", err)
+	}
+	return nil
+}
+
+// Ban permanently bans user from channel. The reason is optional.
+func (bb *BasicBot) Ban(channel, user, reason string) error {
+	if user == "" {
+		return errors.New("BasicBot.Ban: user was empty")
+	}
+	err := bb.moderate(channel, fmt.Sprintf("ban %s %s", user, reason), user, func(ctx context.Context, ids moderationIDs) error {
+		return bb.Helix().BanUser(ctx, ids.broadcaster, ids.moderator, ids.user, 0, reason)
+	})
+	if err != nil {
+		return fmt.Errorf("BasicBot.Ban: %w", err)
+	}
+	return nil
+}
+
+// Unban lifts a ban or timeout of user in channel
+func (bb *BasicBot) Unban(channel, user string) error {
+	if user == "" {
+		return errors.New("BasicBot.Unban: user was empty")
+	}
+	err := bb.moderate(channel, "unban "+user, user, func(ctx context.Context, ids moderationIDs) error {
+		return bb.Helix().UnbanUser(ctx, ids.broadcaster, ids.moderator, ids.user)
+	})
+	if err != nil {
+		return fmt.Errorf("BasicBot.Unban: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the message with the id msgID, found in Message.ID, from channel
+func (bb *BasicBot) Delete(channel, msgID string) error {
+	if msgID == "" {
+		return errors.New("BasicBot.Delete: message id was empty")
+	}
+	err := bb.moderate(channel, "delete "+msgID, "", func(ctx context.Context, ids moderationIDs) error {
+		return bb.Helix().DeleteChatMessage(ctx, ids.broadcaster, ids.moderator, msgID)
+	})
+	if err != nil {
+		return fmt.Errorf("BasicBot.Delete: %w", err)
+	}
+	return nil
+}
+
+// moderationIDs are the user ids a moderation request is made with
+type moderationIDs struct {
+	broadcaster, moderator, user string
+}
+
+// moderate looks up the ids of the broadcaster of channel, the bot and user, when it's set, and
+// makes the request with them. action describes it for DryRun.
+func (bb *BasicBot) moderate(channel, action, user string, request func(ctx context.Context, ids moderationIDs) error) error {
+	channel = NormalizeChannel(channel)
+	if !bb.IsMod(channel) {
+		return fmt.Errorf("#%s: %w", channel, ErrNotModerator)
+	}
+	if bb.DryRun {
+		bb.logger().Infof("dry run, not moderating #%s: %s", channel, strings.TrimSpace(action))
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helixTimeout)
+	defer cancel()
+
+	ids, err := bb.moderationIDs(ctx, channel, strings.ToLower(strings.TrimPrefix(user, "@")))
+	if err != nil {
+		return err
+	}
+	return request(ctx, ids)
+}
+
+// moderationIDs resolves the ids for a moderation request in channel, with user's when it's set.
+// The ids of the broadcaster and the bot are cached, so after the first request in a channel only
+// user's costs a lookup.
+func (bb *BasicBot) moderationIDs(ctx context.Context, channel, user string) (moderationIDs, error) {
+	bot := strings.ToLower(bb.Name)
+	bb.idMu.Lock()
+	ids := moderationIDs{broadcaster: bb.ids[channel], moderator: bb.ids[bot]}
+	bb.idMu.Unlock()
+
+	var logins []string
+	if ids.broadcaster == "" {
+		logins = append(logins, channel)
+	}
+	if ids.moderator == "" {
+		logins = append(logins, bot)
+	}
+	if user != "" {
+		logins = append(logins, user)
+	}
+	if len(logins) == 0 {
+		return ids, nil
+	}
+	found, err := bb.Helix().userIDs(ctx, logins...)
+	if err != nil {
+		return ids, err
+	}
+	byLogin := make(map[string]string, len(logins))
+	for i, login := range logins {
+		byLogin[login] = found[i]
+	}
+	if ids.broadcaster == "" {
+		ids.broadcaster = byLogin[channel]
+	}
+	if ids.moderator == "" {
+		ids.moderator = byLogin[bot]
+	}
+	ids.user = byLogin[user]
+
+	bb.idMu.Lock()
+	if bb.ids == nil {
+		bb.ids = make(map[string]string)
+	}
+	bb.ids[channel] = ids.broadcaster
+	bb.ids[bot] = ids.moderator
+	bb.idMu.Unlock()
+	return ids, nil
+}
+
+// BanUser bans the user with the id userID from the channel of broadcasterID, for duration seconds
+// or permanently when it's zero. The token must belong to moderatorID, a moderator of the
+// channel, and have the moderator:manage:banned_users scope.
+func (h *HelixClient) BanUser(ctx context.Context, broadcasterID, moderatorID, userID string, duration int, reason string) error {
+	type ban struct {
+		UserID   string `json:"user_id"`
+		Duration int    `json:"duration,omitempty"`
+		Reason   string `json:"reason,omitempty"`
+	}
+	body := struct {
+		Data ban `json:"data"`
+	}{ban{userID, duration, reason}}
+	query := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+	err := h.do(ctx, http.MethodPost, "/moderation/bans", query, body, nil)
+	return missingScope(err, "moderator:manage:banned_users")
+}
+
+// UnbanUser lifts the ban or timeout of the user with the id userID in the channel of
+// broadcasterID. The token must belong to moderatorID and have the moderator:manage:banned_users
+// scope.
+func (h *HelixClient) UnbanUser(ctx context.Context, broadcasterID, moderatorID, userID string) error {
+	query := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}, "user_id": {userID}}
+	err := h.do(ctx, http.MethodDelete, "/moderation/bans", query, nil, nil)
+	return missingScope(err, "moderator:manage:banned_users")
+}
+
+// DeleteChatMessage deletes the message with the id messageID from the channel of broadcasterID.
+// The token must belong to moderatorID and have the moderator:manage:chat_messages scope.
+func (h *HelixClient) DeleteChatMessage(ctx context.Context, broadcasterID, moderatorID, messageID string) error {
+	query := url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}, "message_id": {messageID}}
+	err := h.do(ctx, http.MethodDelete, "/moderation/chat", query, nil, nil)
+	return missingScope(err, "moderator:manage:chat_messages")
+}
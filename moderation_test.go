@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// moderationServer stands in for Helix, giving every user the id "id-" + login and recording the
+// user lookups and moderation requests made to it
+type moderationServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	lookups  []string
+	requests []string
+}
+
+func newModerationServer(t *testing.T) *moderationServer {
+	s := &moderationServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users" {
+			var users []string
+			for _, login := range r.URL.Query()["login"] {
+				users = append(users, fmt.Sprintf(`{"id":"id-%s","login":"%s"}`, login, login))
+			}
+			s.mu.Lock()
+			s.lookups = append(s.lookups, strings.Join(r.URL.Query()["login"], ","))
+			s.mu.Unlock()
+			fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(users, ","))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, strings.TrimSpace(fmt.Sprintf("%s %s?%s %s", r.Method, r.URL.Path, r.URL.RawQuery, body)))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *moderationServer) made() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// waitMade waits for n moderation requests, which auto-moderation makes in the background, and
+// returns them sorted
+func (s *moderationServer) waitMade(t *testing.T, n int) []string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.made()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d moderation requests made, want %d: %q", len(s.made()), n, s.made())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	made := s.made()
+	sort.Strings(made)
+	return made
+}
+
+func TestModeration(t *testing.T) {
+	srv := newModerationServer(t)
+	b := &BasicBot{Name: "bot", Moderator: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+
+	for _, send := range []func() error{
+		func() error { return b.Timeout("channel", "spammer", 600, "spamming links") },
+		func() error { return b.Timeout("channel", "spammer", 10, "") },
+		func() error { return b.Ban("channel", "troll", "hate speech") },
+		func() error { return b.Unban("channel", "troll") },
+		func() error { return b.Delete("channel", "b34ccfc7-4977-403a-8a94-33c6bac34fb8") },
+	} {
+		if err := send(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		`POST /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot {"data":{"user_id":"id-spammer","duration":600,"reason":"spamming links"}}`,
+		`POST /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot {"data":{"user_id":"id-spammer","duration":10}}`,
+		`POST /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot {"data":{"user_id":"id-troll","reason":"hate speech"}}`,
+		`DELETE /moderation/bans?broadcaster_id=id-channel&moderator_id=id-bot&user_id=id-troll`,
+		`DELETE /moderation/chat?broadcaster_id=id-channel&message_id=b34ccfc7-4977-403a-8a94-33c6bac34fb8&moderator_id=id-bot`,
+	}
+	if got := srv.made(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// the ids of the channel and the bot are only looked up once
+	wantLookups := []string{"channel,bot,spammer", "spammer", "troll", "troll"}
+	srv.mu.Lock()
+	lookups := srv.lookups
+	srv.mu.Unlock()
+	if strings.Join(lookups, " ") != strings.Join(wantLookups, " ") {
+		t.Errorf("looked up %q, want %q", lookups, wantLookups)
+	}
+
+	if err := b.Timeout("channel", "spammer", 0, ""); err == nil {
+		t.Error("expected an error for a zero second timeout")
+	}
+}
+
+func TestModerationHelixError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users" {
+			w.Write([]byte(`{"data":[{"id":"1","login":"channel"},{"id":"2","login":"bot"},{"id":"3","login":"troll"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"Bad Request","status":400,"message":"The user specified in the user_id field is already banned."}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Moderator: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	var helixErr *HelixError
+	if err := b.Ban("channel", "troll", ""); !errors.As(err, &helixErr) || helixErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Ban returned %v, want the Helix error", err)
+	}
+}
+
+func TestModerationNotModerator(t *testing.T) {
+	b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+	b.setConn(newFakeConn())
+	defer b.Disconnect()
+
+	if err := b.Ban("channel", "troll", ""); !errors.Is(err, ErrNotModerator) {
+		t.Errorf("Ban returned %v, want ErrNotModerator", err)
+	}
+}
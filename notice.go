@@ -0,0 +1,75 @@
+package bot
+
+import "strings"
+
+// msg-ids of common NOTICEs, see https://dev.twitch.tv/docs/irc/msg-id/
+const (
+	// NoticeRateLimit is sent when a message was dropped for going over the message limit
+	NoticeRateLimit = "msg_ratelimit"
+	// NoticeDuplicate is sent when a message was dropped for repeating the previous one within
+	// 30 seconds
+	NoticeDuplicate = "msg_duplicate"
+	// NoticeBanned is sent when a message was dropped because the bot is banned from the channel
+	NoticeBanned = "msg_banned"
+	// NoticeTimedOut is sent when a message was dropped because the bot is timed out
+	NoticeTimedOut = "msg_timedout"
+	// NoticeSlowMode, NoticeFollowersOnly, NoticeSubsOnly and NoticeEmoteOnly are sent when a
+	// message was dropped because of the channel's chat mode
+	NoticeSlowMode      = "msg_slowmode"
+	NoticeFollowersOnly = "msg_followersonly"
+	NoticeSubsOnly      = "msg_subsonly"
+	NoticeEmoteOnly     = "msg_emoteonly"
+)
+
+// Notice is a NOTICE from Twitch, which reports why a message from the bot was dropped, the result
+// of a chat command, or something about the channel
+type Notice struct {
+	Channel string
+	// ID is the notice's msg-id tag, like NoticeRateLimit. It needs the twitch.tv/tags capability.
+	ID string
+	// Text is the notice as Twitch words it for people
+	Text string
+}
+
+// parseNotice builds a Notice from a NOTICE message
+func parseNotice(m *Message) *Notice {
+	return &Notice{Channel: m.Channel, ID: m.Tags["msg-id"], Text: m.Text}
+}
+
+// Dropped reports whether the notice says a message from the bot was dropped
+func (n *Notice) Dropped() bool {
+	return strings.HasPrefix(n.ID, "msg_")
+}
+
+// Err returns the error matching the reason a message was dropped: ErrRateLimited,
+// ErrDuplicateMessage, ErrBanned, or ErrRoomRestricted for the chat modes. Other notices return
+// nil.
+func (n *Notice) Err() error {
+	switch n.ID {
+	case NoticeRateLimit:
+		return ErrRateLimited
+	case NoticeDuplicate:
+		return ErrDuplicateMessage
+	case NoticeBanned, NoticeTimedOut:
+		return ErrBanned
+	case NoticeSlowMode, NoticeFollowersOnly, NoticeSubsOnly, NoticeEmoteOnly:
+		return ErrRoomRestricted
+	}
+	return nil
+}
+
+// handleNotice passes a NOTICE that isn't about logging in to OnNotice
+func handleNotice(m *Message, bb *BasicBot) {
+	n := parseNotice(m)
+	if n.ID == NoticeRateLimit {
+		bb.rateLimited(n.Channel)
+	}
+	if n.Dropped() {
+		bb.logger().Errorf("#%s message dropped, %s: %s", n.Channel, n.ID, n.Text)
+	} else {
+		bb.logger().Infof("#%s NOTICE: %s", n.Channel, n.Text)
+	}
+	if bb.OnNotice != nil {
+		bb.OnNotice(n)
+	}
+}
@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNotice(t *testing.T) {
+	tests := []struct {
+		line    string
+		id      string
+		dropped bool
+		err     error
+	}{
+		{"@msg-id=msg_ratelimit :tmi.twitch.tv NOTICE #dallas :Your message was not sent because you are sending messages too quickly.", NoticeRateLimit, true, ErrRateLimited},
+		{"@msg-id=msg_duplicate :tmi.twitch.tv NOTICE #dallas :Your message was not sent because it is identical to the previous one you sent, less than 30 seconds ago.", NoticeDuplicate, true, ErrDuplicateMessage},
+		{"@msg-id=msg_banned :tmi.twitch.tv NOTICE #dallas :You are permanently banned from talking in dallas.", NoticeBanned, true, ErrBanned},
+		{"@msg-id=msg_subsonly :tmi.twitch.tv NOTICE #dallas :This room is in subscribers only mode.", NoticeSubsOnly, true, ErrRoomRestricted},
+		{"@msg-id=emote_only_on :tmi.twitch.tv NOTICE #dallas :This room is now in emote-only mode.", "emote_only_on", false, nil},
+	}
+
+	var got []*Notice
+	b := &BasicBot{Logger: NopLogger{}, OnNotice: func(n *Notice) { got = append(got, n) }}
+	for _, tt := range tests {
+		m, err := ParseMessage(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleNotice(m, b)
+
+		n := got[len(got)-1]
+		if n.Channel != "dallas" || n.ID != tt.id || n.Text != m.Text {
+			t.Errorf("parsed %+v", n)
+		}
+		if n.Dropped() != tt.dropped {
+			t.Errorf("%s: Dropped() = %v", n.ID, n.Dropped())
+		}
+		if err := n.Err(); !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+			t.Errorf("%s: Err() = %v, want %v", n.ID, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Option configures a bot built by New. Options check their values, so a mistake is reported by
+// New rather than when the bot connects.
+type Option func(cfg *Config) error
+
+// New creates a bot configured by opts, with the default commands registered. It's the preferred
+// way to create a bot:
+//
+//	bb, err := bot.New(
+//		bot.WithChannels("dallas"),
+//		bot.WithCredentials(bot.FileCredentials{Path: "private.json"}),
+//	)
+//
+// A channel and credentials, or WithAnonymous, are required. Everything else has the defaults
+// documented on each option.
+func New(opts ...Option) (*BasicBot, error) {
+	var cfg Config
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, fmt.Errorf("New: %w", err)
+		}
+	}
+	return NewBasicBot(cfg)
+}
+
+// WithChannels adds channels to join, with or without their leading "#"
+func WithChannels(channels ...string) Option {
+	return func(cfg *Config) error {
+		for _, channel := range channels {
+			channel = NormalizeChannel(channel)
+			if channel == "" || strings.ContainsAny(channel, " ,") {
+				return fmt.Errorf("WithChannels: invalid channel %q", channel)
+			}
+			cfg.Channels = append(cfg.Channels, channel)
+		}
+		return nil
+	}
+}
+
+// WithCredentials sets where the credentials are loaded from, like FileCredentials or
+// EnvCredentials
+func WithCredentials(source CredentialSource) Option {
+	return func(cfg *Config) error {
+		if source == nil {
+			return errors.New("WithCredentials: source is nil")
+		}
+		cfg.CredentialSource = source
+		return nil
+	}
+}
+
+// WithAnonymous reads chat without credentials, see BasicBot.Anonymous
+func WithAnonymous() Option {
+	return func(cfg *Config) error {
+		cfg.Anonymous = true
+		return nil
+	}
+}
+
+// WithName sets the bot's username. Defaults to the username in the credentials.
+func WithName(name string) Option {
+	return func(cfg *Config) error {
+		cfg.Name = strings.ToLower(name)
+		return nil
+	}
+}
+
+// WithServer sets the chat server's address as "host" or "host:port". Defaults to DefaultServer
+// on DefaultPort, or DefaultTLSPort with WithTLS.
+func WithServer(addr string) Option {
+	return func(cfg *Config) error {
+		host, port := addr, ""
+		if strings.Contains(addr, ":") {
+			var err error
+			if host, port, err = net.SplitHostPort(addr); err != nil {
+				return fmt.Errorf("WithServer: %w", err)
+			}
+		}
+		if host == "" {
+			return fmt.Errorf("WithServer: no host in %q", addr)
+		}
+		cfg.Server, cfg.Port = host, port
+		return nil
+	}
+}
+
+// WithTLS connects to the server over TLS
+func WithTLS() Option {
+	return func(cfg *Config) error {
+		cfg.UseTLS = true
+		return nil
+	}
+}
+
+// WithMsgRate sets the minimum time between chat messages. Defaults to DefaultMsgRate.
+func WithMsgRate(rate time.Duration) Option {
+	return func(cfg *Config) error {
+		if rate <= 0 {
+			return fmt.Errorf("WithMsgRate: rate must be positive, got %s", rate)
+		}
+		cfg.MsgRate = rate
+		return nil
+	}
+}
+
+// WithCommandPrefix sets what commands start with. Defaults to DefaultCommandPrefix.
+func WithCommandPrefix(prefix string) Option {
+	return func(cfg *Config) error {
+		if prefix == "" || strings.ContainsAny(prefix, " \t\r\n") {
+			return fmt.Errorf("WithCommandPrefix: invalid prefix %q", prefix)
+		}
+		cfg.CommandPrefix = prefix
+		return nil
+	}
+}
+
+// WithLogger sets where the bot's output goes. Defaults to a StdLogger writing to stdout.
+func WithLogger(logger Logger) Option {
+	return func(cfg *Config) error {
+		if logger == nil {
+			return errors.New("WithLogger: logger is nil")
+		}
+		cfg.Logger = logger
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	b, err := New(
+		WithChannels("#Dallas", "ronni"),
+		WithCredentials(EnvCredentials{}),
+		WithServer("irc.example.com:7000"),
+		WithMsgRate(time.Second),
+		WithCommandPrefix("?"),
+		WithLogger(NopLogger{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.Channels, []string{"dallas", "ronni"}) {
+		t.Errorf("Channels = %v", b.Channels)
+	}
+	if b.Server != "irc.example.com" || b.Port != "7000" || b.MsgRate != time.Second || b.CommandPrefix != "?" {
+		t.Errorf("got %s:%s every %s with prefix %q", b.Server, b.Port, b.MsgRate, b.CommandPrefix)
+	}
+
+	b, err = New(WithChannels("dallas"), WithAnonymous(), WithServer("irc.example.com"), WithTLS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Port != DefaultTLSPort || b.MsgRate != DefaultMsgRate {
+		t.Errorf("got port %s every %s, want the defaults", b.Port, b.MsgRate)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	creds := WithCredentials(EnvCredentials{})
+	for name, opts := range map[string][]Option{
+		"no channel":     {creds},
+		"no credentials": {WithChannels("dallas")},
+		"empty channel":  {WithChannels(""), creds},
+		"nil source":     {WithChannels("dallas"), WithCredentials(nil)},
+		"bad server":     {WithChannels("dallas"), creds, WithServer(":6667")},
+		"zero rate":      {WithChannels("dallas"), creds, WithMsgRate(0)},
+		"spaced prefix":  {WithChannels("dallas"), creds, WithCommandPrefix("! ")},
+		"nil logger":     {WithChannels("dallas"), creds, WithLogger(nil)},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("%s: New succeeded, want an error", name)
+		}
+	}
+}
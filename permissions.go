@@ -0,0 +1,54 @@
+package bot
+
+import "strings"
+
+// Permission is the level a chat user has in a channel. Higher levels include the lower ones.
+type Permission int
+
+// Permission levels, from lowest to highest
+const (
+	PermEveryone Permission = iota
+	PermSubscriber
+	PermVIP
+	PermModerator
+	PermBroadcaster
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PermEveryone:
+		return "everyone"
+	case PermSubscriber:
+		return "subscriber"
+	case PermVIP:
+		return "vip"
+	case PermModerator:
+		return "moderator"
+	case PermBroadcaster:
+		return "broadcaster"
+	}
+	return "unknown"
+}
+
+// Permission returns the level of the user who sent m in its channel, read from the badges, mod,
+// vip and subscriber tags. Without tags only the broadcaster is recognised, by their name matching
+// the channel.
+func (m *Message) Permission() Permission {
+	badges := make(map[string]bool)
+	for _, badge := range strings.Split(m.Tags["badges"], ",") {
+		name, _, _ := strings.Cut(badge, "/")
+		badges[name] = true
+	}
+
+	switch {
+	case badges["broadcaster"] || (m.User != "" && m.User == m.Channel):
+		return PermBroadcaster
+	case badges["moderator"] || m.Tags["mod"] == "1":
+		return PermModerator
+	case badges["vip"] || m.Tags["vip"] == "1":
+		return PermVIP
+	case badges["subscriber"] || badges["founder"] || m.Tags["subscriber"] == "1":
+		return PermSubscriber
+	}
+	return PermEveryone
+}
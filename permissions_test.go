@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMessagePermission(t *testing.T) {
+	tests := []struct {
+		line string
+		want Permission
+	}{
+		{"@badges=broadcaster/1,subscriber/12 :owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :hi", PermBroadcaster},
+		{"@badges=moderator/1;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #owner :hi", PermModerator},
+		{"@badges=;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #owner :hi", PermModerator},
+		{"@badges=vip/1 :vip!vip@vip.tmi.twitch.tv PRIVMSG #owner :hi", PermVIP},
+		{"@badges=subscriber/6;subscriber=1 :sub!sub@sub.tmi.twitch.tv PRIVMSG #owner :hi", PermSubscriber},
+		{"@badges=founder/0 :sub!sub@sub.tmi.twitch.tv PRIVMSG #owner :hi", PermSubscriber},
+		{"@badges=;mod=0;subscriber=0 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #owner :hi", PermEveryone},
+		{":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #owner :hi", PermEveryone},
+		{":owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :hi", PermBroadcaster},
+	}
+	for _, tt := range tests {
+		m, err := ParseMessage(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Permission(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterCommandFor(t *testing.T) {
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+
+	var ran []string
+	b.RegisterCommandFor(PermModerator, "clear", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		ran = append(ran, msg.User)
+		return nil
+	})
+
+	for _, line := range []string{
+		"@badges=;mod=0 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #owner :!clear",
+		"@badges=moderator/1;mod=1 :mod!mod@mod.tmi.twitch.tv PRIVMSG #owner :!clear",
+		"@badges=broadcaster/1 :owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!clear",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatPrivMsg(context.Background(), m, b)
+	}
+
+	if len(ran) != 2 || ran[0] != "mod" || ran[1] != "owner" {
+		t.Errorf("ran for %v, want [mod owner]", ran)
+	}
+}
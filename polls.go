@@ -0,0 +1,260 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits Twitch puts on polls, with lengths counted in characters
+const (
+	MinPollChoices      = 2
+	MaxPollChoices      = 5
+	MaxPollTitleLength  = 60
+	MaxPollChoiceLength = 25
+	MinPollDuration     = 15 * time.Second
+	MaxPollDuration     = 30 * time.Minute
+)
+
+// DefaultPollDuration is how long polls started with !poll run when it isn't given
+const DefaultPollDuration = time.Minute
+
+// how often, and how many times, a poll that should have ended is checked for its result
+const (
+	pollResultCheckPeriod = 5 * time.Second
+	pollResultChecks      = 6
+)
+
+// HelixPoll is a poll in a channel
+type HelixPoll struct {
+	ID            string            `json:"id"`
+	BroadcasterID string            `json:"broadcaster_id"`
+	Title         string            `json:"title"`
+	Choices       []HelixPollChoice `json:"choices"`
+	// Status is ACTIVE while users can vote, then COMPLETED, or TERMINATED when ended early
+	Status string `json:"status"`
+	// Duration is how long the poll runs for, in seconds
+	Duration  int       `json:"duration"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
+
+// HelixPollChoice is one of the answers to a poll
+type HelixPollChoice struct {
+	ID                 string `json:"id"`
+	Title              string `json:"title"`
+	Votes              int    `json:"votes"`
+	ChannelPointsVotes int    `json:"channel_points_votes"`
+}
+
+// Winners returns the choices with the most votes, more than one when they're tied
+func (p *HelixPoll) Winners() []HelixPollChoice {
+	var winners []HelixPollChoice
+	for _, choice := range p.Choices {
+		switch {
+		case len(winners) == 0 || choice.Votes > winners[0].Votes:
+			winners = []HelixPollChoice{choice}
+		case choice.Votes == winners[0].Votes:
+			winners = append(winners, choice)
+		}
+	}
+	return winners
+}
+
+// CreatePoll starts a poll in the channel of broadcasterID. The token must belong to the
+// broadcaster and have the channel:manage:polls scope.
+func (h *HelixClient) CreatePoll(ctx context.Context, broadcasterID, title string, choices []string, duration time.Duration) (*HelixPoll, error) {
+	type choice struct {
+		Title string `json:"title"`
+	}
+	body := struct {
+		BroadcasterID string   `json:"broadcaster_id"`
+		Title         string   `json:"title"`
+		Choices       []choice `json:"choices"`
+		Duration      int      `json:"duration"`
+	}{BroadcasterID: broadcasterID, Title: title, Duration: int(duration / time.Second)}
+	for _, c := range choices {
+		body.Choices = append(body.Choices, choice{c})
+	}
+
+	var polls []HelixPoll
+	if err := h.do(ctx, http.MethodPost, "/polls", nil, body, &polls); err != nil {
+		return nil, missingScope(err, "channel:manage:polls")
+	}
+	if len(polls) == 0 {
+		return nil, errors.New("HelixClient.CreatePoll: no poll in the response")
+	}
+	return &polls[0], nil
+}
+
+// GetPoll returns the poll with the id in the channel of broadcasterID, with its votes so far.
+// The token must belong to the broadcaster and have the channel:read:polls or
+// channel:manage:polls scope.
+func (h *HelixClient) GetPoll(ctx context.Context, broadcasterID, id string) (*HelixPoll, error) {
+	var polls []HelixPoll
+	query := url.Values{"broadcaster_id": {broadcasterID}, "id": {id}}
+	if err := h.do(ctx, http.MethodGet, "/polls", query, nil, &polls); err != nil {
+		return nil, missingScope(err, "channel:read:polls")
+	}
+	if len(polls) == 0 {
+		return nil, fmt.Errorf("HelixClient.GetPoll: no poll with id %s", id)
+	}
+	return &polls[0], nil
+}
+
+// CreatePoll starts a poll in channel asking title, with 2 to 5 choices, for a duration between
+// 15 seconds and 30 minutes. When it ends, the bot says which choice won.
+//
+// The bot's token must belong to the broadcaster and have the channel:manage:polls scope.
+func (bb *BasicBot) CreatePoll(channel, title string, choices []string, duration time.Duration) (*HelixPoll, error) {
+	return bb.CreatePollContext(context.Background(), channel, title, choices, duration)
+}
+
+// CreatePollContext is like CreatePoll, with ctx bounding the requests that create the poll. The
+// result is still said once the poll ends, whatever happens to ctx, unless the bot loses the
+// connection it was created on first.
+func (bb *BasicBot) CreatePollContext(ctx context.Context, channel, title string, choices []string, duration time.Duration) (*HelixPoll, error) {
+	if err := checkPoll(title, choices, duration); err != nil {
+		return nil, fmt.Errorf("BasicBot.CreatePoll: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	broadcasterID, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return nil, fmt.Errorf("BasicBot.CreatePoll: %w", err)
+	}
+	poll, err := helix.CreatePoll(ctx, broadcasterID, title, choices, duration)
+	if err != nil {
+		return nil, fmt.Errorf("BasicBot.CreatePoll: %w", err)
+	}
+	bb.connMu.Lock()
+	stop := bb.chatStop
+	bb.connMu.Unlock()
+	go bb.awaitPoll(channel, poll, duration, stop)
+	return poll, nil
+}
+
+// checkPoll checks a poll against Twitch's limits
+func checkPoll(title string, choices []string, duration time.Duration) error {
+	if title == "" || utf8.RuneCountInString(title) > MaxPollTitleLength {
+		return fmt.Errorf("the title must be 1 to %d characters", MaxPollTitleLength)
+	}
+	if len(choices) < MinPollChoices || len(choices) > MaxPollChoices {
+		return fmt.Errorf("a poll needs %d to %d choices, not %d", MinPollChoices, MaxPollChoices, len(choices))
+	}
+	for _, choice := range choices {
+		if choice == "" || utf8.RuneCountInString(choice) > MaxPollChoiceLength {
+			return fmt.Errorf("choice %q must be 1 to %d characters", choice, MaxPollChoiceLength)
+		}
+	}
+	if duration < MinPollDuration || duration > MaxPollDuration {
+		return fmt.Errorf("a poll must run for %s to %s, not %s", MinPollDuration, MaxPollDuration, duration)
+	}
+	return nil
+}
+
+// awaitPoll waits for poll to end, then says the result in channel. It gives up when stop is
+// closed, as the connection the poll was created on is gone.
+func (bb *BasicBot) awaitPoll(channel string, poll *HelixPoll, wait time.Duration, stop <-chan struct{}) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for i := 0; i < pollResultChecks; i++ {
+		select {
+		case <-stop:
+			bb.logger().Infof("poll %q: disconnected before it ended", poll.Title)
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), helixTimeout)
+		result, err := bb.Helix().GetPoll(ctx, poll.BroadcasterID, poll.ID)
+		cancel()
+		if err != nil {
+			bb.logger().Errorf("poll %q: %s", poll.Title, err)
+			return
+		}
+		if result.Status != "ACTIVE" {
+			if err := bb.Say(channel, pollResult(result)); err != nil {
+				bb.logger().Errorf("poll %q: %s", poll.Title, err)
+			}
+			return
+		}
+		// Twitch takes a moment to close the poll
+		timer.Reset(pollResultCheckPeriod)
+	}
+	bb.logger().Errorf("poll %q still open after %s", poll.Title, wait)
+}
+
+// pollResult is the message announcing the result of poll
+func pollResult(poll *HelixPoll) string {
+	winners := poll.Winners()
+	if len(winners) == 0 || winners[0].Votes == 0 {
+		return fmt.Sprintf("Poll %q ended without any votes", poll.Title)
+	}
+	if len(winners) > 1 {
+		titles := make([]string, len(winners))
+		for i, choice := range winners {
+			titles[i] = choice.Title
+		}
+		return fmt.Sprintf("Poll %q ended in a tie between %s with %d votes each", poll.Title, strings.Join(titles, ", "), winners[0].Votes)
+	}
+	return fmt.Sprintf("Poll %q ended: %s won with %d votes", poll.Title, winners[0].Title, winners[0].Votes)
+}
+
+// EnablePolls adds !poll for moderators, e.g. !poll "Best snack?" "Chips" "Cookies" 60, which
+// starts a poll with CreatePoll. The number of seconds at the end is optional and defaults to
+// DefaultPollDuration.
+func (bb *BasicBot) EnablePolls() {
+	bb.RegisterCommandFor(PermModerator, "poll", cmdPoll)
+	bb.SetCommandDescription("poll", `starts a poll, e.g. !poll "Question" "A" "B" 60`)
+}
+
+func cmdPoll(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	words := splitQuoted(cmd.RawArgs)
+	duration := DefaultPollDuration
+	if n := len(words); n > 0 {
+		if seconds, err := strconv.Atoi(words[n-1]); err == nil {
+			duration = time.Duration(seconds) * time.Second
+			words = words[:n-1]
+		}
+	}
+	if len(words) < 1+MinPollChoices {
+		return errors.New(`usage: !poll "question" "choice" "choice" [seconds]`)
+	}
+	poll, err := bb.CreatePollContext(ctx, msg.Channel, words[0], words[1:], duration)
+	if err != nil {
+		return err
+	}
+	return bb.Say(msg.Channel, fmt.Sprintf("Poll %q is open for %s, vote now!", poll.Title, duration))
+}
+
+// splitQuoted splits s into words, keeping the words between double quotes together
+func splitQuoted(s string) []string {
+	var words []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return words
+		}
+		if s[0] == '"' {
+			if end := strings.IndexByte(s[1:], '"'); end >= 0 {
+				words = append(words, s[1:end+1])
+				s = s[end+2:]
+				continue
+			}
+		}
+		word, rest, _ := strings.Cut(s, " ")
+		words = append(words, word)
+		s = rest
+	}
+}
@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreatePoll(t *testing.T) {
+	var posted struct {
+		BroadcasterID string `json:"broadcaster_id"`
+		Title         string `json:"title"`
+		Choices       []struct {
+			Title string `json:"title"`
+		} `json:"choices"`
+		Duration int `json:"duration"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/polls":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"data":[{"id":"p1","broadcaster_id":"123","title":"Best snack?","status":"ACTIVE","duration":60,
+				"choices":[{"id":"c1","title":"Chips","votes":0},{"id":"c2","title":"Cookies","votes":0}]}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/polls":
+			if r.URL.RawQuery != "broadcaster_id=123&id=p1" {
+				t.Errorf("GET /polls?%s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"data":[{"id":"p1","broadcaster_id":"123","title":"Best snack?","status":"COMPLETED","duration":60,
+				"choices":[{"id":"c1","title":"Chips","votes":3},{"id":"c2","title":"Cookies","votes":7}]}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	logger := &recordLogger{}
+	b := &BasicBot{Name: "bot", DryRun: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: logger}
+	poll, err := b.CreatePoll("dallas", "Best snack?", []string{"Chips", "Cookies"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted.BroadcasterID != "123" || posted.Title != "Best snack?" || len(posted.Choices) != 2 || posted.Choices[1].Title != "Cookies" || posted.Duration != 60 {
+		t.Errorf("posted %+v", posted)
+	}
+
+	b.awaitPoll("dallas", poll, 0, nil)
+	if !logger.contains(`Poll "Best snack?" ended: Cookies won with 7 votes`) {
+		t.Errorf("result not announced, logged %q", logger.lines)
+	}
+}
+
+func TestAwaitPollStops(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", DryRun: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		b.awaitPoll("dallas", &HelixPoll{ID: "p1", BroadcasterID: "123", Title: "Best snack?"}, time.Hour, stop)
+		close(done)
+	}()
+
+	// the connection the poll was created on is gone
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("awaitPoll kept waiting after stop was closed")
+	}
+}
+
+func TestPollCommand(t *testing.T) {
+	var posted struct {
+		Title   string `json:"title"`
+		Choices []struct {
+			Title string `json:"title"`
+		} `json:"choices"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/polls":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"data":[{"id":"p1","broadcaster_id":"123","title":"Best  snack?","status":"ACTIVE","duration":60}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", DryRun: true, Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	b.EnablePolls()
+	handleChatPrivMsg(context.Background(), &Message{User: "dallas", Channel: "dallas", Text: `!poll "Best  snack?" "Salt  and vinegar" Cookies 60`}, b)
+
+	// the spacing inside quotes is kept as typed
+	if posted.Title != "Best  snack?" || len(posted.Choices) != 2 || posted.Choices[0].Title != "Salt  and vinegar" {
+		t.Errorf("posted %+v", posted)
+	}
+}
+
+func TestCreatePollLimits(t *testing.T) {
+	b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+	for _, tt := range []struct {
+		title    string
+		choices  []string
+		duration time.Duration
+	}{
+		{"", []string{"a", "b"}, time.Minute},
+		{strings.Repeat("x", MaxPollTitleLength+1), []string{"a", "b"}, time.Minute},
+		{"one choice", []string{"a"}, time.Minute},
+		{"six choices", []string{"a", "b", "c", "d", "e", "f"}, time.Minute},
+		{"long choice", []string{"a", strings.Repeat("x", MaxPollChoiceLength+1)}, time.Minute},
+		{"too short", []string{"a", "b"}, 10 * time.Second},
+		{"too long", []string{"a", "b"}, time.Hour},
+	} {
+		if _, err := b.CreatePoll("dallas", tt.title, tt.choices, tt.duration); err == nil {
+			t.Errorf("CreatePoll(%q, %q, %s) succeeded", tt.title, tt.choices, tt.duration)
+		}
+	}
+}
+
+func TestCreatePollMissingScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Missing scope: channel:manage:polls"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	if _, err := b.CreatePoll("dallas", "Q?", []string{"a", "b"}, time.Minute); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("got %v, want ErrMissingScope", err)
+	}
+}
+
+func TestPollResult(t *testing.T) {
+	tie := &HelixPoll{Title: "Q", Choices: []HelixPollChoice{{Title: "A", Votes: 2}, {Title: "B", Votes: 2}, {Title: "C", Votes: 1}}}
+	if got, want := pollResult(tie), `Poll "Q" ended in a tie between A, B with 2 votes each`; got != want {
+		t.Errorf("pollResult = %q, want %q", got, want)
+	}
+	if got := splitQuoted(`"Best snack?" Chips "Cookies and cream" 60`); !reflect.DeepEqual(got, []string{"Best snack?", "Chips", "Cookies and cream", "60"}) {
+		t.Errorf("splitQuoted = %q", got)
+	}
+}
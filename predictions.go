@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits Twitch puts on predictions, with lengths counted in characters
+const (
+	MinPredictionOutcomes      = 2
+	MaxPredictionOutcomes      = 10
+	MaxPredictionTitleLength   = 45
+	MaxPredictionOutcomeLength = 25
+	MinPredictionWindow        = 30 * time.Second
+	MaxPredictionWindow        = 30 * time.Minute
+)
+
+// Statuses of a prediction
+const (
+	// PredictionActive predictions are taking bets
+	PredictionActive = "ACTIVE"
+	// PredictionLocked predictions no longer take bets, and wait to be resolved
+	PredictionLocked = "LOCKED"
+	// PredictionResolved predictions have paid out to the users who bet on the winning outcome
+	PredictionResolved = "RESOLVED"
+	// PredictionCanceled predictions have refunded every bet
+	PredictionCanceled = "CANCELED"
+)
+
+// HelixPrediction is a prediction in a channel
+type HelixPrediction struct {
+	ID            string                   `json:"id"`
+	BroadcasterID string                   `json:"broadcaster_id"`
+	Title         string                   `json:"title"`
+	Outcomes      []HelixPredictionOutcome `json:"outcomes"`
+	// WinningOutcomeID is set once the prediction is resolved
+	WinningOutcomeID string `json:"winning_outcome_id"`
+	// Status is one of the Prediction statuses
+	Status string `json:"status"`
+	// PredictionWindow is how long bets are taken for, in seconds
+	PredictionWindow int       `json:"prediction_window"`
+	CreatedAt        time.Time `json:"created_at"`
+	// LockedAt is zero until the prediction stops taking bets, and EndedAt until it's resolved
+	// or canceled
+	LockedAt time.Time `json:"locked_at"`
+	EndedAt  time.Time `json:"ended_at"`
+}
+
+// HelixPredictionOutcome is one of the outcomes users bet on
+type HelixPredictionOutcome struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	Users         int    `json:"users"`
+	ChannelPoints int    `json:"channel_points"`
+	// Color is BLUE or PINK
+	Color string `json:"color"`
+}
+
+// CreatePrediction starts a prediction in the channel of broadcasterID, taking bets for window.
+// The token must belong to the broadcaster and have the channel:manage:predictions scope.
+func (h *HelixClient) CreatePrediction(ctx context.Context, broadcasterID, title string, outcomes []string, window time.Duration) (*HelixPrediction, error) {
+	type outcome struct {
+		Title string `json:"title"`
+	}
+	body := struct {
+		BroadcasterID    string    `json:"broadcaster_id"`
+		Title            string    `json:"title"`
+		Outcomes         []outcome `json:"outcomes"`
+		PredictionWindow int       `json:"prediction_window"`
+	}{BroadcasterID: broadcasterID, Title: title, PredictionWindow: int(window / time.Second)}
+	for _, o := range outcomes {
+		body.Outcomes = append(body.Outcomes, outcome{o})
+	}
+	return h.prediction(ctx, http.MethodPost, body)
+}
+
+// EndPrediction locks, resolves or cancels the prediction with the id in the channel of
+// broadcasterID, as status says. winningOutcomeID is needed to resolve it, and ignored otherwise.
+// The token must belong to the broadcaster and have the channel:manage:predictions scope.
+func (h *HelixClient) EndPrediction(ctx context.Context, broadcasterID, id, status, winningOutcomeID string) (*HelixPrediction, error) {
+	body := struct {
+		BroadcasterID    string `json:"broadcaster_id"`
+		ID               string `json:"id"`
+		Status           string `json:"status"`
+		WinningOutcomeID string `json:"winning_outcome_id,omitempty"`
+	}{broadcasterID, id, status, winningOutcomeID}
+	return h.prediction(ctx, http.MethodPatch, body)
+}
+
+// prediction sends body to the predictions endpoint, returning the prediction in the response
+func (h *HelixClient) prediction(ctx context.Context, method string, body interface{}) (*HelixPrediction, error) {
+	var predictions []HelixPrediction
+	if err := h.do(ctx, method, "/predictions", nil, body, &predictions); err != nil {
+		return nil, missingScope(err, "channel:manage:predictions")
+	}
+	if len(predictions) == 0 {
+		return nil, errors.New("HelixClient: no prediction in the response")
+	}
+	return &predictions[0], nil
+}
+
+// CreatePrediction starts a prediction in channel asking title, with 2 to 10 outcomes, taking
+// bets for a window between 30 seconds and 30 minutes. The outcomes of the prediction returned
+// have the ids ResolvePrediction takes.
+//
+// The bot's token must belong to the broadcaster and have the channel:manage:predictions scope.
+func (bb *BasicBot) CreatePrediction(channel, title string, outcomes []string, window time.Duration) (*HelixPrediction, error) {
+	return bb.CreatePredictionContext(context.Background(), channel, title, outcomes, window)
+}
+
+// CreatePredictionContext is like CreatePrediction, giving up on the requests once ctx is done
+func (bb *BasicBot) CreatePredictionContext(ctx context.Context, channel, title string, outcomes []string, window time.Duration) (*HelixPrediction, error) {
+	if err := checkPrediction(title, outcomes, window); err != nil {
+		return nil, fmt.Errorf("BasicBot.CreatePrediction: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	broadcasterID, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return nil, fmt.Errorf("BasicBot.CreatePrediction: %w", err)
+	}
+	prediction, err := helix.CreatePrediction(ctx, broadcasterID, title, outcomes, window)
+	if err != nil {
+		return nil, fmt.Errorf("BasicBot.CreatePrediction: %w", err)
+	}
+	return prediction, nil
+}
+
+// ResolvePrediction ends the prediction with the id in channel, paying out to the users who bet
+// on the outcome with the id winningOutcomeID
+func (bb *BasicBot) ResolvePrediction(channel, id, winningOutcomeID string) (*HelixPrediction, error) {
+	return bb.ResolvePredictionContext(context.Background(), channel, id, winningOutcomeID)
+}
+
+// ResolvePredictionContext is like ResolvePrediction, giving up on the requests once ctx is done
+func (bb *BasicBot) ResolvePredictionContext(ctx context.Context, channel, id, winningOutcomeID string) (*HelixPrediction, error) {
+	if winningOutcomeID == "" {
+		return nil, errors.New("BasicBot.ResolvePrediction: winningOutcomeID was empty")
+	}
+	prediction, err := bb.endPrediction(ctx, channel, id, PredictionResolved, winningOutcomeID)
+	if err != nil {
+		return nil, fmt.Errorf("BasicBot.ResolvePrediction: %w", err)
+	}
+	return prediction, nil
+}
+
+// CancelPrediction ends the prediction with the id in channel, refunding every bet
+func (bb *BasicBot) CancelPrediction(channel, id string) (*HelixPrediction, error) {
+	return bb.CancelPredictionContext(context.Background(), channel, id)
+}
+
+// CancelPredictionContext is like CancelPrediction, giving up on the requests once ctx is done
+func (bb *BasicBot) CancelPredictionContext(ctx context.Context, channel, id string) (*HelixPrediction, error) {
+	prediction, err := bb.endPrediction(ctx, channel, id, PredictionCanceled, "")
+	if err != nil {
+		return nil, fmt.Errorf("BasicBot.CancelPrediction: %w", err)
+	}
+	return prediction, nil
+}
+
+func (bb *BasicBot) endPrediction(ctx context.Context, channel, id, status, winningOutcomeID string) (*HelixPrediction, error) {
+	if id == "" {
+		return nil, errors.New("id was empty")
+	}
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	broadcasterID, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	return helix.EndPrediction(ctx, broadcasterID, id, status, winningOutcomeID)
+}
+
+// checkPrediction checks a prediction against Twitch's limits
+func checkPrediction(title string, outcomes []string, window time.Duration) error {
+	if title == "" || utf8.RuneCountInString(title) > MaxPredictionTitleLength {
+		return fmt.Errorf("the title must be 1 to %d characters", MaxPredictionTitleLength)
+	}
+	if len(outcomes) < MinPredictionOutcomes || len(outcomes) > MaxPredictionOutcomes {
+		return fmt.Errorf("a prediction needs %d to %d outcomes, not %d", MinPredictionOutcomes, MaxPredictionOutcomes, len(outcomes))
+	}
+	for _, outcome := range outcomes {
+		if outcome == "" || utf8.RuneCountInString(outcome) > MaxPredictionOutcomeLength {
+			return fmt.Errorf("outcome %q must be 1 to %d characters", outcome, MaxPredictionOutcomeLength)
+		}
+	}
+	if window < MinPredictionWindow || window > MaxPredictionWindow {
+		return fmt.Errorf("a prediction must take bets for %s to %s, not %s", MinPredictionWindow, MaxPredictionWindow, window)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPredictions(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+		case r.URL.Path == "/predictions" && (r.Method == http.MethodPost || r.Method == http.MethodPatch):
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies = append(bodies, body)
+			status := "ACTIVE"
+			if s, ok := body["status"].(string); ok {
+				status = s
+			}
+			w.Write([]byte(`{"data":[{"id":"p1","broadcaster_id":"123","title":"Win?","status":"` + status + `","prediction_window":120,
+				"outcomes":[{"id":"o1","title":"Yes","color":"BLUE"},{"id":"o2","title":"No","users":4,"channel_points":500,"color":"PINK"}]}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	prediction, err := b.CreatePrediction("dallas", "Win?", []string{"Yes", "No"}, 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prediction.Status != PredictionActive || len(prediction.Outcomes) != 2 || prediction.Outcomes[1].ChannelPoints != 500 {
+		t.Errorf("created %+v", prediction)
+	}
+
+	resolved, err := b.ResolvePrediction("dallas", prediction.ID, prediction.Outcomes[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Status != PredictionResolved {
+		t.Errorf("resolved prediction is %s", resolved.Status)
+	}
+	if _, err := b.CancelPrediction("dallas", prediction.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("%d requests, want 3", len(bodies))
+	}
+	if bodies[0]["prediction_window"] != float64(120) || bodies[0]["broadcaster_id"] != "123" {
+		t.Errorf("created with %v", bodies[0])
+	}
+	if bodies[1]["status"] != PredictionResolved || bodies[1]["winning_outcome_id"] != "o2" || bodies[1]["id"] != "p1" {
+		t.Errorf("resolved with %v", bodies[1])
+	}
+	if _, ok := bodies[2]["winning_outcome_id"]; ok || bodies[2]["status"] != PredictionCanceled {
+		t.Errorf("canceled with %v", bodies[2])
+	}
+}
+
+func TestPredictionLimits(t *testing.T) {
+	b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+	eleven := strings.Split("a b c d e f g h i j k", " ")
+	for _, tt := range []struct {
+		title    string
+		outcomes []string
+		window   time.Duration
+	}{
+		{strings.Repeat("x", MaxPredictionTitleLength+1), []string{"a", "b"}, time.Minute},
+		{"one", []string{"a"}, time.Minute},
+		{"eleven", eleven, time.Minute},
+		{"long outcome", []string{"a", strings.Repeat("x", MaxPredictionOutcomeLength+1)}, time.Minute},
+		{"short window", []string{"a", "b"}, 10 * time.Second},
+	} {
+		if _, err := b.CreatePrediction("dallas", tt.title, tt.outcomes, tt.window); err == nil {
+			t.Errorf("CreatePrediction(%q, %q, %s) succeeded", tt.title, tt.outcomes, tt.window)
+		}
+	}
+	if _, err := b.ResolvePrediction("dallas", "p1", ""); err == nil {
+		t.Error("resolved without a winning outcome")
+	}
+}
+
+func TestPredictionMissingScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/predictions" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Missing scope: channel:manage:predictions"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"123","login":"dallas"}]}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	if _, err := b.CancelPrediction("dallas", "p1"); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("got %v, want ErrMissingScope", err)
+	}
+}
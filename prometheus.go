@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultPrometheusBuckets are the upper bounds, in seconds, of the histogram buckets durations
+// are counted in unless PrometheusMetrics.Buckets is set
+var DefaultPrometheusBuckets = []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// help text for the metrics the bot reports
+var prometheusHelp = map[string]string{
+	MetricMessagesReceived: "Lines received from the chat server.",
+	MetricMessagesSent:     "Chat messages sent.",
+	MetricCommandsHandled:  "Commands run.",
+	MetricCommandDuration:  "Time taken by command handlers.",
+	MetricConnects:         "Connections made to the chat server.",
+	MetricReconnects:       "Reconnect attempts after losing the connection.",
+	MetricErrors:           "Connection, parsing and command errors.",
+}
+
+// PrometheusMetrics is a Metrics serving its measurements to Prometheus. It's an http.Handler
+// writing them in Prometheus' text format, to be mounted at /metrics:
+//
+//	http.Handle("/metrics", bot.NewPrometheusMetrics(bb))
+//
+// Counters are exported as <Namespace>_<name>_total, durations as <Namespace>_<name>_seconds
+// histograms, and whether the bot is connected as the <Namespace>_connected gauge. It's written
+// without Prometheus' client library, so using the bot doesn't pull it in.
+type PrometheusMetrics struct {
+	// Namespace prefixes the metric names. Defaults to "twitchbot".
+	Namespace string
+	// Buckets are the histogram bucket upper bounds in seconds, in increasing order. Defaults to
+	// DefaultPrometheusBuckets. Set it before the first duration is observed.
+	Buckets []float64
+
+	bb         *BasicBot
+	mu         sync.Mutex
+	counters   map[string]uint64
+	histograms map[string]*histogram
+}
+
+// histogram counts observations in buckets, with counts[i] the observations no greater than
+// buckets[i]
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusMetrics creates a PrometheusMetrics for bb and makes it bb's Metrics
+func NewPrometheusMetrics(bb *BasicBot) *PrometheusMetrics {
+	p := &PrometheusMetrics{
+		bb:         bb,
+		counters:   make(map[string]uint64),
+		histograms: make(map[string]*histogram),
+	}
+	// the bot's metrics start at zero rather than being missing until they first happen
+	for name := range prometheusHelp {
+		if name != MetricCommandDuration {
+			p.counters[name] = 0
+		}
+	}
+	bb.Metrics = p
+	return p
+}
+
+// Inc adds one to the counter name
+func (p *PrometheusMetrics) Inc(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.counters[name]++
+}
+
+// Observe records d in the histogram name
+func (p *PrometheusMetrics) Observe(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	buckets := p.buckets()
+	h, ok := p.histograms[name]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		p.histograms[name] = h
+	}
+
+	seconds := d.Seconds()
+	for i, bound := range buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (p *PrometheusMetrics) buckets() []float64 {
+	if len(p.Buckets) == 0 {
+		return DefaultPrometheusBuckets
+	}
+	return p.Buckets
+}
+
+func (p *PrometheusMetrics) namespace() string {
+	if p.Namespace == "" {
+		return "twitchbot"
+	}
+	return p.Namespace
+}
+
+// ServeHTTP writes the metrics in Prometheus' text exposition format
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	ns := p.namespace()
+	connected := 0
+	if p.bb != nil && p.bb.IsConnected() {
+		connected = 1
+	}
+	fmt.Fprintf(out, "# HELP %s_connected Whether the bot is connected to the chat server.\n", ns)
+	fmt.Fprintf(out, "# TYPE %s_connected gauge\n", ns)
+	fmt.Fprintf(out, "%s_connected %d\n", ns, connected)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.counters))
+	for name := range p.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metric := ns + "_" + name + "_total"
+		writeHelp(out, metric, name, "counter")
+		fmt.Fprintf(out, "%s %d\n", metric, p.counters[name])
+	}
+
+	buckets := p.buckets()
+	names = names[:0]
+	for name := range p.histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h := p.histograms[name]
+		metric := ns + "_" + name + "_seconds"
+		writeHelp(out, metric, name, "histogram")
+		for i, bound := range buckets {
+			fmt.Fprintf(out, "%s_bucket{le=%q} %d\n", metric, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(out, "%s_bucket{le=\"+Inf\"} %d\n", metric, h.count)
+		fmt.Fprintf(out, "%s_sum %s\n", metric, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count %d\n", metric, h.count)
+	}
+}
+
+// writeHelp writes the HELP and TYPE lines of metric, which exports the bot's metric name
+func writeHelp(out *bufio.Writer, metric, name, kind string) {
+	if help, ok := prometheusHelp[name]; ok {
+		fmt.Fprintf(out, "# HELP %s %s\n", metric, help)
+	}
+	fmt.Fprintf(out, "# TYPE %s %s\n", metric, kind)
+}
@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	p := NewPrometheusMetrics(b)
+	if b.Metrics != p {
+		t.Fatal("NewPrometheusMetrics did not set the bot's Metrics")
+	}
+	p.Buckets = []float64{.01, .1, 1}
+
+	b.metrics().Inc(MetricMessagesReceived)
+	b.metrics().Inc(MetricMessagesReceived)
+	b.metrics().Observe(MetricCommandDuration, 50*time.Millisecond)
+	b.metrics().Observe(MetricCommandDuration, 2*time.Second)
+	b.setState(StateConnected)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE twitchbot_connected gauge\ntwitchbot_connected 1\n",
+		"# TYPE twitchbot_messages_received_total counter\ntwitchbot_messages_received_total 2\n",
+		"twitchbot_reconnects_total 0\n",
+		"# TYPE twitchbot_command_duration_seconds histogram\n",
+		`twitchbot_command_duration_seconds_bucket{le="0.01"} 0` + "\n",
+		`twitchbot_command_duration_seconds_bucket{le="0.1"} 1` + "\n",
+		`twitchbot_command_duration_seconds_bucket{le="1"} 1` + "\n",
+		`twitchbot_command_duration_seconds_bucket{le="+Inf"} 2` + "\n",
+		"twitchbot_command_duration_seconds_sum 2.05\n",
+		"twitchbot_command_duration_seconds_count 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
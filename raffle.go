@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrRaffleClosed is returned when entering a raffle that isn't open
+	ErrRaffleClosed = errors.New("the raffle isn't open")
+	// ErrNoEntries is returned when drawing a raffle nobody entered
+	ErrNoEntries = errors.New("nobody entered the raffle")
+)
+
+// raffles are the raffles running in each channel
+type raffles struct {
+	mu       sync.Mutex
+	channels map[string]*raffle
+}
+
+// raffle is a channel's current raffle. It stays drawable after closing, until it's drawn or
+// another is opened.
+type raffle struct {
+	open    bool
+	entries []raffleEntry
+}
+
+// raffleEntry is a user entered into a raffle, with how many tickets they hold
+type raffleEntry struct {
+	user   string
+	weight int
+}
+
+// EnableRaffles adds the raffle commands: !enter for anyone to enter the open raffle, and for
+// moderators !raffle open, !raffle close and !draw.
+func (bb *BasicBot) EnableRaffles() {
+	bb.RegisterCommand("enter", cmdEnterRaffle)
+	bb.RegisterCommandFor(PermModerator, "raffle", cmdRaffle)
+	bb.RegisterCommandFor(PermModerator, "draw", cmdDraw)
+	bb.SetCommandDescription("enter", "enters the raffle")
+	bb.SetCommandDescription("raffle", "opens or closes the raffle, with !raffle open or !raffle close")
+	bb.SetCommandDescription("draw", "picks the raffle's winner")
+}
+
+// OpenRaffle opens a new raffle in channel, dropping the entries of the last one
+func (bb *BasicBot) OpenRaffle(channel string) {
+	bb.raffles.mu.Lock()
+	defer bb.raffles.mu.Unlock()
+
+	if bb.raffles.channels == nil {
+		bb.raffles.channels = make(map[string]*raffle)
+	}
+	bb.raffles.channels[NormalizeChannel(channel)] = &raffle{open: true}
+}
+
+// CloseRaffle stops users entering the raffle in channel, which can still be drawn
+func (bb *BasicBot) CloseRaffle(channel string) {
+	bb.raffles.mu.Lock()
+	defer bb.raffles.mu.Unlock()
+
+	if r := bb.raffles.channels[NormalizeChannel(channel)]; r != nil {
+		r.open = false
+	}
+}
+
+// EnterRaffle enters user into the raffle open in channel, returning false if they already were.
+// Users with a weight above one are that many times as likely to win.
+func (bb *BasicBot) EnterRaffle(channel, user string, weight int) (bool, error) {
+	if weight < 1 {
+		weight = 1
+	}
+	user = strings.ToLower(user)
+
+	bb.raffles.mu.Lock()
+	defer bb.raffles.mu.Unlock()
+
+	r := bb.raffles.channels[NormalizeChannel(channel)]
+	if r == nil || !r.open {
+		return false, fmt.Errorf("BasicBot.EnterRaffle: %w", ErrRaffleClosed)
+	}
+	for _, entry := range r.entries {
+		if entry.user == user {
+			return false, nil
+		}
+	}
+	r.entries = append(r.entries, raffleEntry{user, weight})
+	return true, nil
+}
+
+// Draw picks the winner of the raffle in channel, closing it, and says who won. The entries are
+// dropped, so each raffle is drawn once.
+func (bb *BasicBot) Draw(channel string) (string, error) {
+	channel = NormalizeChannel(channel)
+
+	bb.raffles.mu.Lock()
+	r := bb.raffles.channels[channel]
+	delete(bb.raffles.channels, channel)
+	bb.raffles.mu.Unlock()
+
+	if r == nil || len(r.entries) == 0 {
+		return "", fmt.Errorf("BasicBot.Draw: %w", ErrNoEntries)
+	}
+	total := 0
+	for _, entry := range r.entries {
+		total += entry.weight
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(total)))
+	if err != nil {
+		return "", fmt.Errorf("BasicBot.Draw: %w", err)
+	}
+	winner := pickEntry(r.entries, int(n.Int64()))
+
+	if err := bb.Say(channel, fmt.Sprintf("@%s won the raffle, out of %d entries!", winner, len(r.entries))); err != nil {
+		return winner, fmt.Errorf("BasicBot.Draw: %w", err)
+	}
+	return winner, nil
+}
+
+// pickEntry returns the user holding ticket n, counting each entry's weight in tickets
+func pickEntry(entries []raffleEntry, n int) string {
+	for _, entry := range entries {
+		if n < entry.weight {
+			return entry.user
+		}
+		n -= entry.weight
+	}
+	return ""
+}
+
+func cmdEnterRaffle(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	weight := 1
+	if bb.RaffleSubscriberWeight > 1 && msg.Permission() >= PermSubscriber {
+		weight = bb.RaffleSubscriberWeight
+	}
+	entered, err := bb.EnterRaffle(msg.Channel, msg.User, weight)
+	if errors.Is(err, ErrRaffleClosed) {
+		return bb.Say(msg.Channel, fmt.Sprintf("@%s there's no raffle open", msg.User))
+	}
+	if err != nil || !entered {
+		return err
+	}
+	return bb.Say(msg.Channel, fmt.Sprintf("@%s you're in!", msg.User))
+}
+
+func cmdRaffle(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	if len(cmd.Args) == 0 {
+		return errors.New("usage: !raffle open|close")
+	}
+	switch strings.ToLower(cmd.Args[0]) {
+	case "open":
+		bb.OpenRaffle(msg.Channel)
+		return bb.Say(msg.Channel, fmt.Sprintf("The raffle is open, type %senter to join!", bb.commandPrefix()))
+	case "close":
+		bb.CloseRaffle(msg.Channel)
+		return bb.Say(msg.Channel, "The raffle is closed")
+	}
+	return errors.New("usage: !raffle open|close")
+}
+
+func cmdDraw(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	_, err := bb.Draw(msg.Channel)
+	if errors.Is(err, ErrNoEntries) {
+		return bb.Say(msg.Channel, "Nobody entered the raffle")
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRaffle(t *testing.T) {
+	logger := &recordLogger{}
+	b := &BasicBot{Channel: "channel", DryRun: true, Logger: logger, RaffleSubscriberWeight: 3}
+	b.EnableRaffles()
+	run := func(user, text string, tags map[string]string) {
+		handleChatPrivMsg(context.Background(), &Message{User: user, Channel: "channel", Text: text, Tags: tags}, b)
+	}
+	mod := map[string]string{"mod": "1"}
+	sub := map[string]string{"subscriber": "1"}
+
+	if _, err := b.EnterRaffle("channel", "early", 1); !errors.Is(err, ErrRaffleClosed) {
+		t.Errorf("entering before opening returned %v, want ErrRaffleClosed", err)
+	}
+	run("viewer", "!raffle open", nil)
+	run("mod", "!raffle open", mod)
+	run("viewer", "!enter", nil)
+	run("viewer", "!enter", nil)
+	run("Subscriber", "!enter", sub)
+	run("mod", "!raffle close", mod)
+	run("late", "!enter", nil)
+
+	b.raffles.mu.Lock()
+	entries := append([]raffleEntry(nil), b.raffles.channels["channel"].entries...)
+	b.raffles.mu.Unlock()
+	want := []raffleEntry{{"viewer", 1}, {"subscriber", 3}}
+	if len(entries) != len(want) || entries[0] != want[0] || entries[1] != want[1] {
+		t.Fatalf("entries = %v, want %v", entries, want)
+	}
+
+	// the subscriber holds tickets 1 to 3
+	for ticket, winner := range []string{"viewer", "subscriber", "subscriber", "subscriber"} {
+		if got := pickEntry(entries, ticket); got != winner {
+			t.Errorf("ticket %d won by %q, want %q", ticket, got, winner)
+		}
+	}
+
+	run("mod", "!draw", mod)
+	if !logger.contains("won the raffle, out of 2 entries!") {
+		t.Error("the winner wasn't announced")
+	}
+	if _, err := b.Draw("channel"); !errors.Is(err, ErrNoEntries) {
+		t.Errorf("drawing twice returned %v, want ErrNoEntries", err)
+	}
+}
+
+func TestRaffleOpenUsesPrefix(t *testing.T) {
+	logger := &recordLogger{}
+	b := &BasicBot{Channel: "channel", DryRun: true, Logger: logger, CommandPrefix: "?"}
+	b.EnableRaffles()
+	handleChatPrivMsg(context.Background(), &Message{User: "channel", Channel: "channel", Text: "?raffle open"}, b)
+	if !logger.contains("type ?enter to join!") {
+		t.Errorf("announced the raffle with the wrong prefix, logged %q", logger.lines)
+	}
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultMessageLimit is the number of messages Twitch allows a regular user per 30 seconds
+	DefaultMessageLimit = 20
+	// DefaultModMessageLimit is the number of messages Twitch allows a moderator or VIP per 30
+	// seconds
+	DefaultModMessageLimit = 100
+
+	// the window Twitch's chat limits are counted over
+	rateLimitWindow = 30 * time.Second
+)
+
+// rateLimiter is a token bucket where every message spends a token that only returns to the
+// bucket one window after it was spent, so no window ever holds more messages than the budget.
+type rateLimiter struct {
+	mu   sync.Mutex
+	sent []time.Time // times of the messages sent within the last window, oldest first
+
+	waiting int64
+}
+
+// wait blocks until a message can be sent without exceeding limit messages per window
+func (r *rateLimiter) wait(limit int, window time.Duration) {
+	atomic.AddInt64(&r.waiting, 1)
+	defer atomic.AddInt64(&r.waiting, -1)
+
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		expired := 0
+		for expired < len(r.sent) && now.Sub(r.sent[expired]) >= window {
+			expired++
+		}
+		r.sent = r.sent[expired:]
+
+		if len(r.sent) < limit {
+			r.sent = append(r.sent, now)
+			r.mu.Unlock()
+			return
+		}
+		delay := window - now.Sub(r.sent[0])
+		r.mu.Unlock()
+
+		time.Sleep(delay)
+	}
+}
+
+// queued returns the number of callers blocked in wait
+func (r *rateLimiter) queued() int {
+	return int(atomic.LoadInt64(&r.waiting))
+}
+
+// messageLimit is the number of messages the bot may send per window to channel
+func (bb *BasicBot) messageLimit(channel string) int {
+	if bb.selfPermission(channel) >= PermVIP {
+		if bb.ModMessageLimit > 0 {
+			return bb.ModMessageLimit
+		}
+		return DefaultModMessageLimit
+	}
+	if bb.MessageLimit > 0 {
+		return bb.MessageLimit
+	}
+	return DefaultMessageLimit
+}
+
+// QueueDepth returns the number of messages waiting to be sent because the rate limit was reached.
+// Callers can use it to drop messages rather than queue them behind a long backlog.
+func (bb *BasicBot) QueueDepth() int {
+	return bb.limiter.queued()
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var r rateLimiter
+	window := 100 * time.Millisecond
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		r.wait(3, window)
+	}
+	if elapsed := time.Since(start); elapsed >= window {
+		t.Fatalf("messages within the budget were delayed by %s", elapsed)
+	}
+
+	// the fourth message has to wait for the first token to come back
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.wait(3, window)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if depth := r.queued(); depth != 1 {
+		t.Errorf("queued() = %d, want 1", depth)
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < window {
+		t.Errorf("message over the budget sent after %s, want at least %s", elapsed, window)
+	}
+}
+
+func TestMessageLimit(t *testing.T) {
+	b := BasicBot{}
+	if got := b.messageLimit("channel"); got != DefaultMessageLimit {
+		t.Errorf("messageLimit() = %d, want %d", got, DefaultMessageLimit)
+	}
+	b.Moderator = true
+	if got := b.messageLimit("channel"); got != DefaultModMessageLimit {
+		t.Errorf("moderator messageLimit() = %d, want %d", got, DefaultModMessageLimit)
+	}
+
+	b = BasicBot{}
+	m, _ := ParseMessage("@badges=vip/1;color=;display-name=Bot;emote-sets=0;mod=0;subscriber=0 :tmi.twitch.tv USERSTATE #channel")
+	handleUserState(m, &b)
+	if got := b.messageLimit("channel"); got != DefaultModMessageLimit {
+		t.Errorf("VIP messageLimit() = %d, want %d", got, DefaultModMessageLimit)
+	}
+	if got := b.messageLimit("other"); got != DefaultMessageLimit {
+		t.Errorf("messageLimit() elsewhere = %d, want %d", got, DefaultMessageLimit)
+	}
+}
@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SendRaw writes line to the server as it is, for IRC commands the bot has no method for, like a
+// new Twitch chat command or capability. "\r\n" is added to the end when it's missing.
+//
+// It's for power users: the line isn't formatted as a PRIVMSG, and doesn't count towards or wait
+// for the message limit Say respects, though a PRIVMSG is still spaced by MsgRate. The line can't
+// hold CR, LF or NUL characters, which would let it smuggle in further commands.
+func (bb *BasicBot) SendRaw(line string) error {
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return fmt.Errorf("BasicBot.SendRaw: %w", ErrEmptyMessage)
+	}
+	if err := checkLine(line); err != nil {
+		return fmt.Errorf("BasicBot.SendRaw: %w", err)
+	}
+	if !bb.connected() {
+		return fmt.Errorf("BasicBot.SendRaw: %w", ErrNotConnected)
+	}
+	if err := bb.send(line + "\r\n"); err != nil {
+		return fmt.Errorf("BasicBot.SendRaw: %w", err)
+	}
+	return nil
+}
+
+// checkLine returns ErrInvalidCharacters if s holds a character that would end an IRC line early
+func checkLine(s string) error {
+	if i := strings.IndexAny(s, "\r\n\x00"); i >= 0 {
+		return fmt.Errorf("%w: %q at position %d", ErrInvalidCharacters, s[i], i)
+	}
+	return nil
+}
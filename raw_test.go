@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSendRaw(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	if err := b.SendRaw("CAP REQ :twitch.tv/tags"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("SendRaw before Connect returned %v, want ErrNotConnected", err)
+	}
+
+	conn := newFakeConn()
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	if err := b.SendRaw("CAP REQ :twitch.tv/tags"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SendRaw("CAP REQ :twitch.tv/commands\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "CAP REQ :twitch.tv/tags\r\nCAP REQ :twitch.tv/commands\r\n")
+
+	for _, line := range []string{"", "PRIVMSG #channel :hi\r\nPART #channel", "NICK bot\n", "JOIN #a\x00b"} {
+		if err := b.SendRaw(line); err == nil {
+			t.Errorf("SendRaw(%q) succeeded", line)
+		}
+	}
+}
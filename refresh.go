@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OAuthTokenURL is Twitch's endpoint for exchanging a refresh token for a new access token
+const OAuthTokenURL = "https://id.twitch.tv/oauth2/token"
+
+// CredentialSaver is implemented by credential sources that can store refreshed credentials
+type CredentialSaver interface {
+	SaveCredentials(cred *OAuthCred) error
+}
+
+// SaveCredentials writes cred back to the file. The file is replaced atomically so a crash
+// mid-write can't lose the credentials.
+func (f FileCredentials) SaveCredentials(cred *OAuthCred) error {
+	data, err := json.MarshalIndent(cred, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(f.Path, append(data, '\n')); err != nil {
+		return fmt.Errorf("FileCredentials: cannot save credentials: %w", err)
+	}
+	return nil
+}
+
+// canRefresh reports whether the credentials hold everything needed to refresh the token
+func (c *OAuthCred) canRefresh() bool {
+	return c != nil && c.RefreshToken != "" && c.ClientID != "" && c.ClientSecret != ""
+}
+
+// RefreshCredentials exchanges the refresh token for a new access token and saves the result
+// back to the credential source when it supports it.
+//
+// Twitch only accepts each refresh token once, so calls made while a refresh is running wait for
+// it and return its result rather than starting another.
+func (bb *BasicBot) RefreshCredentials(ctx context.Context) error {
+	return bb.refreshCredentials(ctx, nil)
+}
+
+// credRefresh is a refresh of the credentials in progress, whose err is set once done is closed
+type credRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// refreshCredentials refreshes the token, unless stale is set and the credentials have already
+// been replaced since it was read, so callers that were rejected with the same old token refresh it
+// only once between them
+func (bb *BasicBot) refreshCredentials(ctx context.Context, stale *OAuthCred) error {
+	bb.credMu.Lock()
+	if stale != nil && bb.Credentials != stale {
+		bb.credMu.Unlock()
+		return nil
+	}
+	if r := bb.refresh; r != nil {
+		bb.credMu.Unlock()
+		select {
+		case <-r.done:
+			return r.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	r := &credRefresh{done: make(chan struct{})}
+	bb.refresh = r
+	cred := bb.Credentials
+	bb.credMu.Unlock()
+
+	r.err = bb.exchangeRefreshToken(ctx, cred)
+	bb.credMu.Lock()
+	bb.refresh = nil
+	bb.credMu.Unlock()
+	close(r.done)
+	return r.err
+}
+
+// exchangeRefreshToken makes the request for RefreshCredentials with the refresh token of cred
+func (bb *BasicBot) exchangeRefreshToken(ctx context.Context, cred *OAuthCred) error {
+	if !cred.canRefresh() {
+		return errors.New("BasicBot.RefreshCredentials: refresh_token, client_id and client_secret are required")
+	}
+
+	tokenURL := bb.TokenURL
+	if tokenURL == "" {
+		tokenURL = OAuthTokenURL
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {cred.RefreshToken},
+		"client_id":     {cred.ClientID},
+		"client_secret": {cred.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := bb.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("BasicBot.RefreshCredentials: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("BasicBot.RefreshCredentials: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return fmt.Errorf("BasicBot.RefreshCredentials: unexpected response %q", body)
+	}
+
+	refreshed := *cred
+	refreshed.Password = "oauth:" + token.AccessToken
+	if token.RefreshToken != "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	bb.credMu.Lock()
+	bb.Credentials = &refreshed
+	bb.credMu.Unlock()
+	bb.logger().Infof("Refreshed the OAuth token")
+
+	if saver, ok := bb.credentialSource().(CredentialSaver); ok {
+		if err := saver.SaveCredentials(&refreshed); err != nil {
+			return fmt.Errorf("BasicBot.RefreshCredentials: %w", err)
+		}
+	}
+	return nil
+}
+
+// credentials returns Credentials, which RefreshCredentials replaces from whichever goroutine's
+// request was rejected
+func (bb *BasicBot) credentials() *OAuthCred {
+	bb.credMu.Lock()
+	defer bb.credMu.Unlock()
+
+	return bb.Credentials
+}
+
+func (bb *BasicBot) httpClient() *http.Client {
+	if bb.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return bb.HTTPClient
+}
+
+// isAuthFailure reports whether msg is the NOTICE Twitch sends before dropping a connection whose
+// PASS or NICK was rejected
+func isAuthFailure(msg *Message) bool {
+	return msg.Type == "NOTICE" &&
+		(msg.Text == "Login authentication failed" || msg.Text == "Improperly formatted auth")
+}
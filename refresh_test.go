@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRefreshCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "old-refresh" ||
+			r.Form.Get("client_id") != "client" || r.Form.Get("client_secret") != "secret" {
+			t.Errorf("bad form: %v", r.Form)
+		}
+		w.Write([]byte(`{"access_token":"new-token","refresh_token":"new-refresh","scope":["chat:read"],"token_type":"bearer"}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "creds.json")
+	os.WriteFile(path, []byte(`{"password":"oauth:old-token","refresh_token":"old-refresh","client_id":"client","client_secret":"secret"}`), 0600)
+
+	b := &BasicBot{PrivatePath: path, TokenURL: srv.URL, Logger: NopLogger{}}
+	if err := b.ReadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RefreshCredentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if b.Credentials.Password != "oauth:new-token" || b.Credentials.RefreshToken != "new-refresh" {
+		t.Errorf("credentials not updated: %+v", b.Credentials)
+	}
+
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), "oauth:new-token") || !strings.Contains(string(saved), "new-refresh") {
+		t.Errorf("refreshed token not persisted: %s", saved)
+	}
+}
+
+func TestRefreshCredentialsMissingFields(t *testing.T) {
+	b := &BasicBot{Credentials: &OAuthCred{Password: "oauth:token"}}
+	if err := b.RefreshCredentials(context.Background()); err == nil {
+		t.Error("expected an error without a refresh token")
+	}
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	msg, err := ParseMessage(":tmi.twitch.tv NOTICE * :Login authentication failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isAuthFailure(msg) {
+		t.Error("login failure NOTICE not detected")
+	}
+}
+
+func TestHandleChatAuthFailed(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{Channel: "channel", Credentials: &OAuthCred{Password: "oauth:bad"}, Logger: NopLogger{}}
+	b.setConn(client)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+	server.Write([]byte(":tmi.twitch.tv NOTICE * :Login authentication failed\r\n"))
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("HandleChat returned %v, want ErrAuthFailed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HandleChat did not return after the auth failure")
+	}
+}
+
+func TestStartAbortsOnAuthFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	accepted := make(chan int, 10)
+	go func() {
+		for n := 1; ; n++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- n
+			conn.Write([]byte(":tmi.twitch.tv NOTICE * :Login authentication failed\r\n"))
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	path := filepath.Join(t.TempDir(), "creds.json")
+	os.WriteFile(path, []byte(`{"password":"oauth:bad"}`), 0600)
+	b := &BasicBot{Channel: "channel", Server: host, Port: port, PrivatePath: path, Capabilities: []string{}, ReconnectBase: time.Millisecond, Logger: NopLogger{}}
+
+	result := make(chan error, 1)
+	go func() { result <- b.StartContext(context.Background()) }()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("StartContext returned %v, want ErrAuthFailed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartContext kept retrying after an auth failure")
+	}
+	if len(accepted) != 1 {
+		t.Errorf("connected %d times, want 1", len(accepted))
+	}
+}
@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReplayReader runs the raw IRC lines read from r, such as those captured with OnRawLine, through
+// the bot as if they'd been received from the server, until r is exhausted. Commands run and
+// callbacks are called just as they are live, but without a connection: PINGs, PONGs and login
+// failures are skipped, and commands run one at a time as they're read, whatever CommandWorkers
+// is. Set DryRun to log what handlers say instead of failing with ErrNotConnected.
+//
+// ReplayReader waits ReplayDelay between lines. It mustn't be called while HandleChat is running.
+func (bb *BasicBot) ReplayReader(r io.Reader) error {
+	ctx := context.Background()
+	tp := bb.newReader(r)
+	for first := true; ; first = false {
+		line, err := tp.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("BasicBot.ReplayReader: %w", err)
+		}
+		if !first && bb.ReplayDelay > 0 {
+			time.Sleep(bb.ReplayDelay)
+		}
+
+		msg := bb.readMessage(line)
+		if msg == nil {
+			continue
+		}
+		switch msg.Type {
+		case "PING", "PONG":
+			continue
+		case "NOTICE":
+			if isAuthFailure(msg) {
+				continue
+			}
+		}
+		bb.dispatch(ctx, msg)
+	}
+}
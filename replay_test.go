@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayReader(t *testing.T) {
+	capture := strings.Join([]string{
+		"PING :tmi.twitch.tv",
+		":owner!owner@owner.tmi.twitch.tv PRIVMSG #owner :!echo one",
+		"not an irc line",
+		":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #owner :!echo two",
+		":tmi.twitch.tv NOTICE * :Login authentication failed",
+	}, "\r\n") + "\r\n"
+
+	logger := &recordLogger{}
+	b := &BasicBot{Channel: "owner", Name: "bot", DryRun: true, Logger: logger, ReplayDelay: time.Millisecond}
+	var echoed []string
+	b.RegisterCommand("echo", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		echoed = append(echoed, msg.User+": "+strings.Join(cmd.Args, " "))
+		return bb.Say(msg.Channel, strings.Join(cmd.Args, " "))
+	})
+	var raw int
+	b.OnRawLine = func(line string) { raw++ }
+
+	if err := b.ReplayReader(strings.NewReader(capture)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"owner: one", "viewer: two"}; strings.Join(echoed, "|") != strings.Join(want, "|") {
+		t.Errorf("replayed commands %q, want %q", echoed, want)
+	}
+	if raw != 5 {
+		t.Errorf("OnRawLine called %d times, want 5", raw)
+	}
+	if !logger.contains("dry run, not sending to #owner: two") {
+		t.Error("handler's answer wasn't logged")
+	}
+}
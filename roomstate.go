@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoomState holds a channel's chat settings, as sent by ROOMSTATE when the bot joins and whenever
+// a moderator changes one
+type RoomState struct {
+	Channel string
+	// EmoteOnly restricts messages to emotes
+	EmoteOnly bool
+	// FollowersOnly restricts chat to followers, who must have followed for at least
+	// FollowersFor
+	FollowersOnly bool
+	FollowersFor  time.Duration
+	// R9K rejects messages that aren't unique
+	R9K bool
+	// Slow is how long users must wait between messages, zero when slow mode is off
+	Slow time.Duration
+	// SubsOnly restricts chat to subscribers
+	SubsOnly bool
+}
+
+// roomStates keeps the latest RoomState of every channel
+type roomStates struct {
+	mu    sync.Mutex
+	rooms map[string]RoomState
+}
+
+// RoomState returns the chat settings of channel as last sent by Twitch. It's the zero RoomState
+// until the bot has joined channel with the twitch.tv/tags capability.
+func (bb *BasicBot) RoomState(channel string) RoomState {
+	bb.roomStates.mu.Lock()
+	defer bb.roomStates.mu.Unlock()
+
+	return bb.roomStates.get(channel)
+}
+
+// get returns the RoomState of channel. r.mu must be held.
+func (r *roomStates) get(channel string) RoomState {
+	state, ok := r.rooms[channel]
+	if !ok {
+		state.Channel = channel
+	}
+	return state
+}
+
+// update applies the ROOMSTATE m. Only the settings present in its tags are changed, as the
+// ROOMSTATE sent when a setting changes only carries that one.
+func (r *roomStates) update(m *Message) RoomState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rooms == nil {
+		r.rooms = make(map[string]RoomState)
+	}
+	state := r.rooms[m.Channel]
+	state.Channel = m.Channel
+	if v, ok := m.Tags["emote-only"]; ok {
+		state.EmoteOnly = v == "1"
+	}
+	if v, ok := m.Tags["followers-only"]; ok {
+		// -1 when off, otherwise the minutes users must have followed for
+		minutes, err := strconv.Atoi(v)
+		state.FollowersOnly = err == nil && minutes >= 0
+		state.FollowersFor = 0
+		if state.FollowersOnly {
+			state.FollowersFor = time.Duration(minutes) * time.Minute
+		}
+	}
+	if v, ok := m.Tags["r9k"]; ok {
+		state.R9K = v == "1"
+	}
+	if _, ok := m.Tags["slow"]; ok {
+		state.Slow = time.Duration(tagInt(m.Tags, "slow")) * time.Second
+	}
+	if v, ok := m.Tags["subs-only"]; ok {
+		state.SubsOnly = v == "1"
+	}
+	r.rooms[m.Channel] = state
+	return state
+}
+
+// selfPermission is the bot's own level in channel, from its USERSTATE or the Moderator field
+func (bb *BasicBot) selfPermission(channel string) Permission {
+	switch {
+	case strings.EqualFold(bb.Name, channel):
+		return PermBroadcaster
+	case bb.Moderator:
+		return PermModerator
+	}
+	return bb.UserState(channel).Permission
+}
+
+// restriction returns the chat mode stopping the bot from talking in the room, or "" if it can
+func (bb *BasicBot) restriction(state RoomState) string {
+	permission := bb.selfPermission(state.Channel)
+	switch {
+	case permission >= PermModerator:
+		return ""
+	case state.SubsOnly && permission < PermSubscriber:
+		return "subscribers-only"
+	case state.EmoteOnly:
+		return "emote-only"
+	case state.FollowersOnly && !bb.Follower:
+		return "followers-only"
+	}
+	return ""
+}
+
+// checkRoom returns ErrRoomRestricted if the chat modes of channel don't allow the bot to talk,
+// unless WaitForRoomModes is set, in which case the writer holds the line until they do
+func (bb *BasicBot) checkRoom(channel string) error {
+	if bb.WaitForRoomModes {
+		return nil
+	}
+	if mode := bb.restriction(bb.RoomState(channel)); mode != "" {
+		return fmt.Errorf("cannot send to #%s in %s mode: %w", channel, mode, ErrRoomRestricted)
+	}
+	return nil
+}
+
+// chatSlot is the writer's chatSlot: lines to a channel in slow mode are spaced by it, and with
+// WaitForRoomModes set lines wait while the chat modes don't allow the bot to talk. Lines are
+// held by the writer rather than Say, so a handler saying something never stops chat being read.
+func (bb *BasicBot) chatSlot(channel string, last time.Time) (time.Time, bool) {
+	state := bb.RoomState(channel)
+	if bb.WaitForRoomModes && bb.restriction(state) != "" {
+		return time.Time{}, false
+	}
+	if state.Slow <= 0 || last.IsZero() || bb.selfPermission(channel) >= PermModerator {
+		return time.Time{}, true
+	}
+	return last.Add(state.Slow), true
+}
+
+func handleRoomState(m *Message, bb *BasicBot) {
+	state := bb.roomStates.update(m)
+	bb.logger().Debugf("#%s room state: %+v", m.Channel, state)
+	// lines held for the chat modes may go now
+	bb.held.notify()
+}
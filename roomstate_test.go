@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoomState(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	if got := b.RoomState("dallas"); got != (RoomState{Channel: "dallas"}) {
+		t.Errorf("got %+v before any ROOMSTATE", got)
+	}
+
+	for _, line := range []string{
+		"@emote-only=0;followers-only=-1;r9k=0;room-id=12345678;slow=0;subs-only=0 :tmi.twitch.tv ROOMSTATE #dallas",
+		"@room-id=12345678;slow=10 :tmi.twitch.tv ROOMSTATE #dallas",
+		"@followers-only=30;room-id=12345678 :tmi.twitch.tv ROOMSTATE #dallas",
+		"@room-id=12345678;subs-only=1 :tmi.twitch.tv ROOMSTATE #dallas",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleRoomState(m, b)
+	}
+
+	want := RoomState{Channel: "dallas", FollowersOnly: true, FollowersFor: 30 * time.Minute, Slow: 10 * time.Second, SubsOnly: true}
+	if got := b.RoomState("dallas"); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func setRoomState(t *testing.T, b *BasicBot, line string) {
+	t.Helper()
+	m, err := ParseMessage(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handleRoomState(m, b)
+}
+
+func TestSayRoomModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  string
+		setup func(b *BasicBot)
+		ok    bool
+	}{
+		{"subs-only", "subs-only=1", nil, false},
+		{"emote-only", "emote-only=1", nil, false},
+		{"followers-only", "followers-only=10", nil, false},
+		{"followers-only as a follower", "followers-only=10", func(b *BasicBot) { b.Follower = true }, true},
+		{"subs-only as a moderator", "subs-only=1", func(b *BasicBot) { b.Moderator = true }, true},
+		{"subs-only as the broadcaster", "subs-only=1", func(b *BasicBot) { b.Name = "channel" }, true},
+		{"no modes", "subs-only=0;emote-only=0;followers-only=-1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+			b.setConn(newFakeConn())
+			defer b.Disconnect()
+			if tt.setup != nil {
+				tt.setup(b)
+			}
+			setRoomState(t, b, "@"+tt.tags+" :tmi.twitch.tv ROOMSTATE #channel")
+
+			err := b.Say("channel", "hello")
+			if tt.ok && err != nil {
+				t.Errorf("Say returned %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrRoomRestricted) {
+				t.Errorf("Say returned %v, want ErrRoomRestricted", err)
+			}
+		})
+	}
+}
+
+func TestSayWaitForRoomModes(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Name: "bot", WaitForRoomModes: true, Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+	setRoomState(t, b, "@subs-only=1 :tmi.twitch.tv ROOMSTATE #channel")
+
+	// held by the writer rather than blocking Say
+	if err := b.Say("channel", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(conn.written(), "hello") {
+		t.Fatal("sent in subscribers-only mode")
+	}
+
+	setRoomState(t, b, "@subs-only=0 :tmi.twitch.tv ROOMSTATE #channel")
+	conn.waitFor(t, "PRIVMSG #channel :hello\r\n")
+}
+
+func TestWaitForRoomModesDoesNotBlockReading(t *testing.T) {
+	// the ROOMSTATE lifting subs-only mode is read after the command answered while it's on
+	conn := newFakeConn(
+		"@subs-only=1 :tmi.twitch.tv ROOMSTATE #channel\r\n",
+		":channel!channel@channel.tmi.twitch.tv PRIVMSG #channel :!hi\r\n",
+		"@subs-only=0 :tmi.twitch.tv ROOMSTATE #channel\r\n",
+	)
+	b := &BasicBot{Channel: "channel", Name: "bot", WaitForRoomModes: true, Logger: NopLogger{}}
+	b.RegisterCommand("hi", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		return bb.Say(msg.Channel, "hello")
+	})
+	b.setConn(conn)
+	go b.HandleChat()
+	defer b.Disconnect()
+
+	conn.waitFor(t, "PRIVMSG #channel :hello\r\n")
+}
+
+func TestSaySlowMode(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+	b.roomStates.rooms = map[string]RoomState{"channel": {Channel: "channel", Slow: 50 * time.Millisecond}}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := b.Say("channel", fmt.Sprintf("hello %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("Say blocked for %s in slow mode", elapsed)
+	}
+	conn.waitFor(t, "PRIVMSG #channel :hello 2\r\n")
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("three messages sent in %s, want them 50ms apart", elapsed)
+	}
+}
+
+func TestSlowModeOnlyHoldsItsChannel(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+	b.roomStates.rooms = map[string]RoomState{"slow": {Channel: "slow", Slow: time.Minute}}
+
+	b.Say("slow", "one")
+	b.Say("slow", "two")
+	b.Say("fast", "three")
+	conn.waitFor(t, "PRIVMSG #slow :one\r\nPRIVMSG #fast :three\r\n")
+}
@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Twitch's cooldowns on shoutouts from a channel
+const (
+	// shoutoutTargetCooldown is how long before the same channel can be shouted out again
+	shoutoutTargetCooldown = 2 * time.Minute
+	// shoutoutCooldown is how long before any channel can be shouted out
+	shoutoutCooldown = time.Minute
+)
+
+// shoutouts tracks when each channel last gave shoutouts, to respect Twitch's cooldowns
+type shoutouts struct {
+	mu sync.Mutex
+	// last is when each channel last gave a shoutout
+	last map[string]time.Time
+	// lastTarget is when each channel last shouted out each target, keyed by "channel target"
+	lastTarget map[string]time.Time
+}
+
+// SendShoutout gives a shoutout to toBroadcasterID in the chat of fromBroadcasterID. moderatorID
+// is the user giving it, who must be a moderator of the channel, and the token must have the
+// moderator:manage:shoutouts scope.
+func (h *HelixClient) SendShoutout(ctx context.Context, fromBroadcasterID, toBroadcasterID, moderatorID string) error {
+	query := url.Values{
+		"from_broadcaster_id": {fromBroadcasterID},
+		"to_broadcaster_id":   {toBroadcasterID},
+		"moderator_id":        {moderatorID},
+	}
+	err := h.do(ctx, http.MethodPost, "/chat/shoutouts", query, nil, nil)
+	return missingScope(err, "moderator:manage:shoutouts")
+}
+
+// Shoutout gives target a shoutout in channel, which shows viewers a card to follow them. Twitch
+// allows a shoutout a minute, and one every two minutes for the same target, so Shoutout returns
+// ErrOnCooldown rather than calling Twitch sooner.
+//
+// The bot must be a moderator of the channel with the moderator:manage:shoutouts scope. When
+// Twitch doesn't permit the shoutout, the bot says a message pointing to target's channel instead.
+func (bb *BasicBot) Shoutout(channel, target string) error {
+	return bb.ShoutoutContext(context.Background(), channel, target)
+}
+
+// ShoutoutContext is like Shoutout, with ctx bounding the requests to Helix. The fallback message
+// is said whether or not ctx is done.
+func (bb *BasicBot) ShoutoutContext(ctx context.Context, channel, target string) error {
+	channel = NormalizeChannel(channel)
+	target = strings.ToLower(strings.TrimLeft(target, "@#"))
+	if target == "" {
+		return errors.New("BasicBot.Shoutout: target was empty")
+	}
+	undo, err := bb.shoutouts.reserve(channel, target, time.Now())
+	if err != nil {
+		return fmt.Errorf("BasicBot.Shoutout: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, helixTimeout)
+	defer cancel()
+
+	err = bb.sendShoutout(ctx, channel, target)
+	var helixErr *HelixError
+	if errors.As(err, &helixErr) && (helixErr.StatusCode == http.StatusUnauthorized || helixErr.StatusCode == http.StatusForbidden) {
+		bb.logger().Errorf("shoutout to %s not permitted, saying it instead: %s", target, err)
+		err = bb.Say(channel, fmt.Sprintf("Go check out %s at https://twitch.tv/%s", target, target))
+	}
+	if err != nil {
+		undo()
+		return fmt.Errorf("BasicBot.Shoutout: %w", err)
+	}
+	return nil
+}
+
+// sendShoutout resolves the ids a shoutout to target in channel needs and sends it
+func (bb *BasicBot) sendShoutout(ctx context.Context, channel, target string) error {
+	helix := bb.Helix()
+	fromID, err := helix.broadcasterID(ctx, channel)
+	if err != nil {
+		return err
+	}
+	toID, err := helix.broadcasterID(ctx, target)
+	if err != nil {
+		return err
+	}
+	moderatorID, err := helix.broadcasterID(ctx, bb.Name)
+	if err != nil {
+		return err
+	}
+	return helix.SendShoutout(ctx, fromID, toID, moderatorID)
+}
+
+// reserve starts the cooldowns of channel shouting out target at now, or returns ErrOnCooldown,
+// saying how long is left, when they haven't run out. Checking and starting them under one lock
+// means only one of several shoutouts at once gets through. undo puts the cooldowns back as they
+// were, for a shoutout that failed.
+func (s *shoutouts) reserve(channel, target string, now time.Time) (undo func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := channel + " " + target
+	last, lastTarget := s.last[channel], s.lastTarget[key]
+	if wait := last.Add(shoutoutCooldown).Sub(now); wait > 0 {
+		return nil, fmt.Errorf("%w: next shoutout in #%s allowed in %s", ErrOnCooldown, channel, wait.Round(time.Second))
+	}
+	if wait := lastTarget.Add(shoutoutTargetCooldown).Sub(now); wait > 0 {
+		return nil, fmt.Errorf("%w: %s can be shouted out again in %s", ErrOnCooldown, target, wait.Round(time.Second))
+	}
+
+	if s.last == nil {
+		s.last = make(map[string]time.Time)
+		s.lastTarget = make(map[string]time.Time)
+	}
+	s.last[channel] = now
+	s.lastTarget[key] = now
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// unless a later shoutout has started them again
+		if s.last[channel].Equal(now) {
+			s.last[channel] = last
+		}
+		if s.lastTarget[key].Equal(now) {
+			s.lastTarget[key] = lastTarget
+		}
+	}, nil
+}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// shoutoutServer answers user lookups with ids, and shoutouts with status
+func shoutoutServer(t *testing.T, status int, shoutouts *[]string) *httptest.Server {
+	ids := map[string]string{"dallas": "1", "ronni": "2", "bot": "3"}
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			login := r.URL.Query().Get("login")
+			w.Write([]byte(`{"data":[{"id":"` + ids[login] + `","login":"` + login + `"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/chat/shoutouts":
+			mu.Lock()
+			*shoutouts = append(*shoutouts, r.URL.RawQuery)
+			mu.Unlock()
+			w.WriteHeader(status)
+			if status != http.StatusNoContent {
+				w.Write([]byte(`{"error":"Forbidden","status":403,"message":"The user in moderator_id is not one of the broadcaster's moderators."}`))
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+}
+
+func TestShoutout(t *testing.T) {
+	var sent []string
+	srv := shoutoutServer(t, http.StatusNoContent, &sent)
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	if err := b.Shoutout("dallas", "@Ronni"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0] != "from_broadcaster_id=1&moderator_id=3&to_broadcaster_id=2" {
+		t.Errorf("sent %v", sent)
+	}
+
+	if err := b.Shoutout("dallas", "someoneelse"); !errors.Is(err, ErrOnCooldown) {
+		t.Errorf("second shoutout within a minute returned %v, want ErrOnCooldown", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("called Twitch while on cooldown: %v", sent)
+	}
+
+	// after the global cooldown only the same target is still waiting
+	later := time.Now().Add(90 * time.Second)
+	if _, err := b.shoutouts.reserve("dallas", "ronni", later); !errors.Is(err, ErrOnCooldown) {
+		t.Errorf("same target: %v, want ErrOnCooldown", err)
+	}
+	if _, err := b.shoutouts.reserve("dallas", "someoneelse", later); err != nil {
+		t.Errorf("another target: %v", err)
+	}
+}
+
+func TestShoutoutConcurrent(t *testing.T) {
+	var sent []string
+	srv := shoutoutServer(t, http.StatusNoContent, &sent)
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Shoutout("dallas", "ronni")
+		}()
+	}
+	wg.Wait()
+	if len(sent) != 1 {
+		t.Errorf("%d shoutouts sent at once, want 1", len(sent))
+	}
+}
+
+func TestShoutoutFailureUndoesCooldown(t *testing.T) {
+	var sent []string
+	srv := shoutoutServer(t, http.StatusInternalServerError, &sent)
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	if err := b.Shoutout("dallas", "ronni"); err == nil || errors.Is(err, ErrOnCooldown) {
+		t.Fatalf("got %v, want Twitch's error", err)
+	}
+	if _, err := b.shoutouts.reserve("dallas", "ronni", time.Now()); err != nil {
+		t.Errorf("failed shoutout left a cooldown: %v", err)
+	}
+}
+
+func TestShoutoutFallback(t *testing.T) {
+	var sent []string
+	srv := shoutoutServer(t, http.StatusForbidden, &sent)
+	defer srv.Close()
+
+	conn := newFakeConn()
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	if err := b.Shoutout("dallas", "ronni"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "PRIVMSG #dallas :Go check out ronni at https://twitch.tv/ronni\r\n")
+}
@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxSongRequests is how many song requests can wait in the queue by default
+const DefaultMaxSongRequests = 50
+
+// ErrQueueFull is returned when a song request is made while the queue is full
+var ErrQueueFull = errors.New("the song request queue is full")
+
+// SongRequest is a song requested by a viewer, with !sr or by cheering
+type SongRequest struct {
+	User    string `json:"user"`
+	Request string `json:"request"`
+	// Bits is the number of bits cheered with the request, zero for !sr
+	Bits int `json:"bits,omitempty"`
+}
+
+// SongQueue is the queue of song requests, oldest first. It's safe for concurrent use, so an
+// overlay can read it while requests come in.
+type SongQueue struct {
+	mu       sync.Mutex
+	requests []SongRequest
+	// Max is the most requests the queue holds. Defaults to DefaultMaxSongRequests.
+	Max int
+	// changed is called after every change, to save the queue
+	changed func()
+}
+
+// Enqueue adds request by user to the end of the queue
+func (q *SongQueue) Enqueue(user, request string) error {
+	return q.add(SongRequest{User: user, Request: request})
+}
+
+func (q *SongQueue) add(req SongRequest) error {
+	if strings.TrimSpace(req.Request) == "" {
+		return errors.New("SongQueue: request was empty")
+	}
+
+	q.mu.Lock()
+	max := q.Max
+	if max <= 0 {
+		max = DefaultMaxSongRequests
+	}
+	if len(q.requests) >= max {
+		q.mu.Unlock()
+		return fmt.Errorf("SongQueue: %w", ErrQueueFull)
+	}
+	q.requests = append(q.requests, req)
+	q.mu.Unlock()
+
+	q.notify()
+	return nil
+}
+
+// Dequeue removes and returns the oldest request, reporting false if the queue is empty
+func (q *SongQueue) Dequeue() (SongRequest, bool) {
+	q.mu.Lock()
+	if len(q.requests) == 0 {
+		q.mu.Unlock()
+		return SongRequest{}, false
+	}
+	req := q.requests[0]
+	q.requests = q.requests[1:]
+	q.mu.Unlock()
+
+	q.notify()
+	return req, true
+}
+
+// List returns the requests waiting, oldest first
+func (q *SongQueue) List() []SongRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]SongRequest(nil), q.requests...)
+}
+
+// Clear removes every request
+func (q *SongQueue) Clear() {
+	q.mu.Lock()
+	q.requests = nil
+	q.mu.Unlock()
+
+	q.notify()
+}
+
+func (q *SongQueue) notify() {
+	if q.changed != nil {
+		q.changed()
+	}
+}
+
+// SongQueue returns the bot's song request queue
+func (bb *BasicBot) SongQueue() *SongQueue {
+	return &bb.songs
+}
+
+// EnableSongRequests adds the song request commands: !sr <song> for anyone to request a song,
+// !songs to list the queue, and !skip and !clearsongs for moderators. When SongRequestBits is set,
+// cheers of at least that many bits request the rest of their message too.
+func (bb *BasicBot) EnableSongRequests() {
+	bb.songs.changed = bb.saveState
+	bb.songRequests = true
+	bb.RegisterCommand("sr", cmdSongRequest)
+	bb.RegisterCommand("songs", cmdSongs)
+	bb.RegisterCommandFor(PermModerator, "skip", cmdSkipSong)
+	bb.RegisterCommandFor(PermModerator, "clearsongs", cmdClearSongs)
+}
+
+// cheerSongRequest queues the song requested by cheering in m, if it cheered enough
+func (bb *BasicBot) cheerSongRequest(m *Message) {
+	bits := taggedBits(m)
+	if !bb.songRequests || bb.SongRequestBits <= 0 || bits < bb.SongRequestBits {
+		return
+	}
+	request := stripCheermotes(m.Text)
+	if cmd, ok := bb.parseCommand(request); ok && strings.EqualFold(cmd.Name, "sr") {
+		// requested with !sr too, which queues it already
+		return
+	}
+	if err := bb.songs.add(SongRequest{User: m.User, Request: request, Bits: bits}); err != nil {
+		bb.logger().Errorf("song request from %s: %s", m.User, err)
+	}
+}
+
+// taggedBits returns the bits cheered in m according to its bits tag. Unlike m.Bits it never
+// counts cheermotes typed in the text of untagged lines, which anyone can send without paying.
+func taggedBits(m *Message) int {
+	bits, _ := strconv.Atoi(m.Tags["bits"])
+	return bits
+}
+
+// stripCheermotes removes the cheermotes from text
+func stripCheermotes(text string) string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if !cheerRegex.MatchString(word) {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func cmdSongRequest(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	request := stripCheermotes(strings.Join(cmd.Args, " "))
+	if request == "" {
+		return errors.New("usage: !sr <song>")
+	}
+	if err := bb.songs.add(SongRequest{User: msg.User, Request: request, Bits: taggedBits(msg)}); err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			return bb.Say(msg.Channel, fmt.Sprintf("@%s the song queue is full, try again later", msg.User))
+		}
+		return err
+	}
+	return bb.Say(msg.Channel, fmt.Sprintf("@%s added to the queue at #%d", msg.User, len(bb.songs.List())))
+}
+
+func cmdSongs(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	requests := bb.songs.List()
+	if len(requests) == 0 {
+		return bb.Say(msg.Channel, "The song queue is empty")
+	}
+	var list []string
+	for i, req := range requests {
+		if i == 5 {
+			list = append(list, fmt.Sprintf("and %d more", len(requests)-i))
+			break
+		}
+		list = append(list, fmt.Sprintf("%d. %s (%s)", i+1, req.Request, req.User))
+	}
+	return bb.Say(msg.Channel, strings.Join(list, " | "))
+}
+
+func cmdSkipSong(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	req, ok := bb.songs.Dequeue()
+	if !ok {
+		return bb.Say(msg.Channel, "The song queue is empty")
+	}
+	return bb.Say(msg.Channel, fmt.Sprintf("Skipped %s", req.Request))
+}
+
+func cmdClearSongs(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+	bb.songs.Clear()
+	return bb.Say(msg.Channel, "Cleared the song queue")
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSongQueue(t *testing.T) {
+	q := &SongQueue{Max: 2}
+	q.Enqueue("alice", "first")
+	q.Enqueue("bob", "second")
+	if err := q.Enqueue("carol", "third"); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Enqueue on a full queue returned %v, want ErrQueueFull", err)
+	}
+
+	if req, ok := q.Dequeue(); !ok || req.Request != "first" || req.User != "alice" {
+		t.Errorf("Dequeue = %+v, %v; want alice's first", req, ok)
+	}
+	if got := q.List(); len(got) != 1 || got[0].Request != "second" {
+		t.Errorf("List = %+v", got)
+	}
+	q.Clear()
+	if _, ok := q.Dequeue(); ok {
+		t.Error("Dequeue succeeded on a cleared queue")
+	}
+}
+
+func TestSongRequests(t *testing.T) {
+	store := &JSONFileStore{Path: filepath.Join(t.TempDir(), "state.json")}
+	b := &BasicBot{Store: store, SongRequestBits: 100, Logger: NopLogger{}}
+	b.setConn(newFakeConn())
+	defer b.Disconnect()
+	b.EnableSongRequests()
+
+	for _, line := range []string{
+		":alice!alice@alice.tmi.twitch.tv PRIVMSG #channel :!sr never gonna give you up",
+		"@bits=50 :bob!bob@bob.tmi.twitch.tv PRIVMSG #channel :Cheer50 too cheap",
+		":erin!erin@erin.tmi.twitch.tv PRIVMSG #channel :Cheer500 https://youtu.be/dQw4w9WgXcQ",
+		"@badges= :erin!erin@erin.tmi.twitch.tv PRIVMSG #channel :Cheer500 https://youtu.be/dQw4w9WgXcQ",
+		"@bits=100 :carol!carol@carol.tmi.twitch.tv PRIVMSG #channel :Cheer100 darude sandstorm",
+		"@bits=100 :dave!dave@dave.tmi.twitch.tv PRIVMSG #channel :!sr take on me Cheer100",
+		"@badges=;mod=0 :bob!bob@bob.tmi.twitch.tv PRIVMSG #channel :!skip",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatPrivMsg(context.Background(), m, b)
+	}
+
+	want := []SongRequest{
+		{User: "alice", Request: "never gonna give you up"},
+		{User: "carol", Request: "darude sandstorm", Bits: 100},
+		{User: "dave", Request: "take on me", Bits: 100},
+	}
+	got := b.SongQueue().List()
+	if len(got) != len(want) {
+		t.Fatalf("queue %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	restored := &BasicBot{Store: store}
+	// loaded again on every StartContext
+	for i := 0; i < 2; i++ {
+		if err := restored.loadState(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := restored.SongQueue().List(); len(got) != len(want) {
+		t.Errorf("restored queue %+v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the number of characters of text Twitch allows in a chat message. Twitch
+// counts the message text alone, so the limit isn't reduced by the "PRIVMSG #channel :" the bot
+// writes in front of it, or by any tags: a message of exactly MaxMessageLength characters is
+// delivered whole, whatever channel it's sent to.
+const MaxMessageLength = 500
+
+// splitMessage returns msg as the messages to send for it. Messages over MaxMessageLength are an
+// error unless SplitLongMessages is set, when they're split between words.
+//
+// Chat commands like /timeout are never split, as the parts after the first would be sent as
+// plain chat.
+func (bb *BasicBot) splitMessage(msg string) ([]string, error) {
+	length := utf8.RuneCountInString(msg)
+	if length <= MaxMessageLength {
+		return []string{msg}, nil
+	}
+	if !bb.SplitLongMessages || strings.HasPrefix(msg, "/") || strings.HasPrefix(msg, ".") {
+		return nil, fmt.Errorf("%w: %d characters, the limit is %d", ErrMessageTooLong, length, MaxMessageLength)
+	}
+	return splitWords(msg, MaxMessageLength), nil
+}
+
+// splitWords splits text into parts of at most max characters, breaking between words. Words
+// longer than max are broken wherever they need to be.
+func splitWords(text string, max int) []string {
+	var parts []string
+	var part strings.Builder
+	partLen := 0
+
+	flush := func() {
+		if partLen > 0 {
+			parts = append(parts, part.String())
+			part.Reset()
+			partLen = 0
+		}
+	}
+
+	for _, word := range strings.Fields(text) {
+		wordLen := utf8.RuneCountInString(word)
+		if partLen > 0 && partLen+1+wordLen > max {
+			flush()
+		}
+		for wordLen > max {
+			// a word that can't fit in a part of its own
+			cut, n := 0, 0
+			for i := range word {
+				if n == max {
+					cut = i
+					break
+				}
+				n++
+			}
+			parts = append(parts, word[:cut])
+			word, wordLen = word[cut:], wordLen-max
+		}
+		if partLen > 0 {
+			part.WriteByte(' ')
+			partLen++
+		}
+		part.WriteString(word)
+		partLen += wordLen
+	}
+	flush()
+	return parts
+}
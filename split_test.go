@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSayAtLimit(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}}
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	// multi-byte characters count once each
+	atLimit := strings.Repeat("é", MaxMessageLength)
+	if err := b.Say("channel", atLimit); err != nil {
+		t.Fatalf("Say with %d characters: %s", MaxMessageLength, err)
+	}
+	conn.waitFor(t, "PRIVMSG #channel :"+atLimit+"\r\n")
+
+	if err := b.Say("channel", atLimit+"!"); !errors.Is(err, ErrMessageTooLong) {
+		t.Errorf("Say over the limit returned %v, want ErrMessageTooLong", err)
+	}
+}
+
+func TestSaySplitsLongMessages(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}, SplitLongMessages: true}
+	b.setConn(conn)
+	defer b.Disconnect()
+
+	// 300 words of 4 characters and a space each, 1499 characters in all
+	msg := strings.TrimSpace(strings.Repeat("word ", 300))
+	if err := b.Say("channel", msg); err != nil {
+		t.Fatal(err)
+	}
+
+	part := strings.TrimSpace(strings.Repeat("word ", 100))
+	conn.waitFor(t, strings.Repeat("PRIVMSG #channel :"+part+"\r\n", 3))
+
+	if err := b.Say("channel", "/timeout someone 60 "+msg); !errors.Is(err, ErrMessageTooLong) {
+		t.Errorf("long chat command returned %v, want ErrMessageTooLong", err)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	long := strings.Repeat("x", 12)
+	parts := splitWords("hi "+long+" there", 5)
+	want := []string{"hi", "xxxxx", "xxxxx", "xx", "there"}
+	if strings.Join(parts, "|") != strings.Join(want, "|") {
+		t.Errorf("splitWords = %q, want %q", parts, want)
+	}
+
+	for _, part := range splitWords(strings.Repeat("日本語 ", 10), 7) {
+		if n := utf8.RuneCountInString(part); n > 7 || !utf8.ValidString(part) {
+			t.Errorf("part %q has %d characters", part, n)
+		}
+	}
+}
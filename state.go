@@ -0,0 +1,40 @@
+package bot
+
+import "sync/atomic"
+
+// ConnState is the state of the bot's connection to the chat server
+type ConnState int32
+
+// Connection states, in the order a connection goes through them
+const (
+	StateDisconnected ConnState = iota
+	// StateConnecting is from dialing the server until the channels have been joined
+	StateConnecting
+	StateConnected
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	}
+	return "unknown"
+}
+
+// State returns the current state of the connection
+func (bb *BasicBot) State() ConnState {
+	return ConnState(atomic.LoadInt32(&bb.state))
+}
+
+// IsConnected reports whether the bot is connected and has joined its channels
+func (bb *BasicBot) IsConnected() bool {
+	return bb.State() == StateConnected
+}
+
+func (bb *BasicBot) setState(s ConnState) {
+	atomic.StoreInt32(&bb.state, int32(s))
+}
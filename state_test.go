@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+type failingDialer struct{}
+
+func (failingDialer) Dial(network, addr string) (net.Conn, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestConnState(t *testing.T) {
+	conn := newFakeConn()
+	b := &BasicBot{Channel: "channel", Capabilities: []string{}, Credentials: &OAuthCred{Password: "oauth:abc"}, Dialer: &fakeDialer{conn: conn}, Logger: NopLogger{}}
+	if b.State() != StateDisconnected || b.IsConnected() {
+		t.Errorf("got %s before Connect, want disconnected", b.State())
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		if b.State() != StateConnecting {
+			t.Errorf("got %s after Connect, want connecting", b.State())
+		}
+		b.JoinChannel()
+		if !b.IsConnected() {
+			t.Errorf("got %s after JoinChannel, want connected", b.State())
+		}
+		b.Disconnect()
+		if b.State() != StateDisconnected {
+			t.Errorf("got %s after Disconnect, want disconnected", b.State())
+		}
+		conn = newFakeConn()
+		b.Dialer = &fakeDialer{conn: conn}
+	}
+
+	b.Dialer = failingDialer{}
+	if err := b.Connect(); err == nil {
+		t.Fatal("expected Connect to fail")
+	}
+	if b.State() != StateDisconnected {
+		t.Errorf("got %s after a failed Connect, want disconnected", b.State())
+	}
+}
@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StateStore persists the state the bot builds up while running, such as text commands and
+// counters, so it survives restarts. The state is loaded by Start before connecting and saved
+// whenever it changes and on shutdown.
+type StateStore interface {
+	// Load returns the saved state, or an empty one if nothing was saved yet
+	Load() (*BotState, error)
+	Save(state *BotState) error
+}
+
+// BotState is the state saved by a StateStore
+type BotState struct {
+	TextCommands map[string]SavedTextCommand `json:"text_commands,omitempty"`
+	Counters     map[string]int              `json:"counters,omitempty"`
+	SongQueue    []SongRequest               `json:"song_queue,omitempty"`
+	Points       map[string]int              `json:"points,omitempty"`
+}
+
+// SavedTextCommand is a text command added with AddTextCommand or AddTextCommandFor
+type SavedTextCommand struct {
+	Response   string     `json:"response"`
+	Permission Permission `json:"permission,omitempty"`
+}
+
+// JSONFileStore is a StateStore keeping the state in a JSON file at Path. The file is replaced
+// atomically so a crash mid-write can't corrupt it.
+type JSONFileStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Load reads the state from the file, returning an empty state if it doesn't exist yet
+func (s *JSONFileStore) Load() (*BotState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := &BotState{}
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("JSONFileStore: cannot read state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("JSONFileStore: cannot parse %s: %w", s.Path, err)
+	}
+	return state, nil
+}
+
+// Save writes state to the file
+func (s *JSONFileStore) Save(state *BotState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.Path, append(data, '\n')); err != nil {
+		return fmt.Errorf("JSONFileStore: cannot save state: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data by writing a temporary file beside it and
+// renaming it over the original
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadState restores the state saved in Store, if there is one
+func (bb *BasicBot) loadState() error {
+	if bb.Store == nil {
+		return nil
+	}
+	state, err := bb.Store.Load()
+	if err != nil {
+		return fmt.Errorf("BasicBot.loadState: %w", err)
+	}
+
+	d := bb.dispatcher()
+	for name, cmd := range state.TextCommands {
+		d.setTextCommand(name, textCommand{cmd.Response, cmd.Permission})
+	}
+
+	bb.counters.mu.Lock()
+	defer bb.counters.mu.Unlock()
+
+	for name, value := range state.Counters {
+		if bb.counters.values == nil {
+			bb.counters.values = make(map[string]int)
+		}
+		bb.counters.values[name] = value
+	}
+
+	bb.songs.mu.Lock()
+	bb.songs.requests = append([]SongRequest(nil), state.SongQueue...)
+	bb.songs.mu.Unlock()
+
+	bb.loyalty.mu.Lock()
+	bb.loyalty.points = make(map[string]int, len(state.Points))
+	for user, points := range state.Points {
+		bb.loyalty.points[user] = points
+	}
+	bb.loyalty.mu.Unlock()
+	return nil
+}
+
+// saveState saves the current state to Store, if there is one, logging any error
+func (bb *BasicBot) saveState() {
+	if bb.Store == nil {
+		return
+	}
+	// held until saved, so a snapshot can't overwrite a newer one
+	bb.saveMu.Lock()
+	defer bb.saveMu.Unlock()
+
+	state := &BotState{TextCommands: bb.dispatcher().savedTextCommands()}
+	state.Counters = bb.counters.snapshot()
+	state.SongQueue = bb.songs.List()
+	state.Points = bb.loyalty.snapshot()
+
+	if err := bb.Store.Save(state); err != nil {
+		bb.logger().Errorf("%s", err)
+	}
+}
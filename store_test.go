@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONFileStore(t *testing.T) {
+	store := &JSONFileStore{Path: filepath.Join(t.TempDir(), "state.json")}
+
+	state, err := store.Load()
+	if err != nil || len(state.TextCommands) != 0 {
+		t.Fatalf("Load before any Save = %+v, %v; want an empty state", state, err)
+	}
+
+	b := &BasicBot{Store: store, Logger: NopLogger{}}
+	b.AddTextCommand("discord", "join us!")
+	b.AddTextCommandFor(PermModerator, "mods", "mods only")
+	b.RemoveTextCommand("mods")
+
+	restored := &BasicBot{Store: store, Logger: NopLogger{}}
+	if err := restored.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.dispatcher().textCommands; len(got) != 1 || got["discord"].response != "join us!" {
+		t.Errorf("restored text commands %+v", got)
+	}
+}
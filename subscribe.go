@@ -0,0 +1,60 @@
+package bot
+
+import "sync"
+
+// subscriberBuffer is how many messages a subscriber can fall behind before the oldest are dropped
+const subscriberBuffer = 64
+
+// subscribers fans out every received message to the channels returned by Subscribe
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[<-chan Message]chan Message
+}
+
+// Subscribe returns a channel receiving every message parsed from the connection, of any type.
+//
+// The channel is buffered. When a subscriber falls too far behind the oldest messages waiting for
+// it are dropped, so a slow subscriber never holds up the bot. Call Unsubscribe when done.
+func (bb *BasicBot) Subscribe() <-chan Message {
+	bb.subscribers.mu.Lock()
+	defer bb.subscribers.mu.Unlock()
+
+	if bb.subscribers.subs == nil {
+		bb.subscribers.subs = make(map[<-chan Message]chan Message)
+	}
+	ch := make(chan Message, subscriberBuffer)
+	bb.subscribers.subs[ch] = ch
+	return ch
+}
+
+// Unsubscribe stops messages being sent to ch, a channel returned by Subscribe, and closes it
+func (bb *BasicBot) Unsubscribe(ch <-chan Message) {
+	bb.subscribers.mu.Lock()
+	defer bb.subscribers.mu.Unlock()
+
+	if sub, ok := bb.subscribers.subs[ch]; ok {
+		delete(bb.subscribers.subs, ch)
+		close(sub)
+	}
+}
+
+// publish sends m to every subscriber without blocking
+func (s *subscribers) publish(m Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		for sent := false; !sent; {
+			select {
+			case sub <- m:
+				sent = true
+			default:
+				// full, make room by dropping the oldest message
+				select {
+				case <-sub:
+				default:
+				}
+			}
+		}
+	}
+}
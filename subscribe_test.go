@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	conn := newFakeConn(
+		"PING :tmi.twitch.tv\r\n",
+		":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :hello\r\n",
+	)
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}}
+	first, second := b.Subscribe(), b.Subscribe()
+	b.setConn(conn)
+	go b.HandleChat()
+	defer b.Disconnect()
+
+	for _, sub := range []<-chan Message{first, second} {
+		for _, want := range []string{"PING", "PRIVMSG"} {
+			select {
+			case m := <-sub:
+				if m.Type != want {
+					t.Errorf("got a %s message, want %s", m.Type, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("no %s message received", want)
+			}
+		}
+	}
+
+	b.Unsubscribe(first)
+	if _, ok := <-first; ok {
+		t.Error("channel still open after Unsubscribe")
+	}
+}
+
+func TestSubscribeSlowConsumer(t *testing.T) {
+	b := &BasicBot{}
+	sub := b.Subscribe()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		b.subscribers.publish(Message{Text: fmt.Sprint(i)})
+	}
+
+	if m := <-sub; m.Text != "10" {
+		t.Errorf("oldest message kept is %q, want 10", m.Text)
+	}
+}
+
+func TestSubscribeConcurrent(t *testing.T) {
+	b := &BasicBot{}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			b.subscribers.publish(Message{Text: fmt.Sprint(i)})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.Unsubscribe(b.Subscribe())
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Int returns the integer value of the tag key, or zero when it's absent or not a number
+func (m *Message) Int(key string) int {
+	return tagInt(m.Tags, key)
+}
+
+// Bool reports whether the tag key is set to 1 or true, as Twitch's flags like mod and
+// first-msg are
+func (m *Message) Bool(key string) bool {
+	switch strings.TrimSpace(m.Tags[key]) {
+	case "1", "true":
+		return true
+	}
+	return false
+}
+
+// Time returns the time in the tag key, given in milliseconds since the epoch like tmi-sent-ts,
+// or the zero time when it's absent or not a number
+func (m *Message) Time(key string) time.Time {
+	ms, err := strconv.ParseInt(strings.TrimSpace(m.Tags[key]), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// tagInt parses the integer value of a tag, returning zero when it's absent or invalid
+func tagInt(tags map[string]string, key string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(tags[key]))
+	return n
+}
@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTagAccessors(t *testing.T) {
+	m, err := ParseMessage("@mod=1;subscriber=0;bits=100;tmi-sent-ts=1507246572675;slow=abc :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.Bool("mod") || m.Bool("subscriber") || m.Bool("missing") {
+		t.Errorf("Bool: mod %v, subscriber %v, missing %v", m.Bool("mod"), m.Bool("subscriber"), m.Bool("missing"))
+	}
+	if got := m.Int("bits"); got != 100 {
+		t.Errorf("Int(bits) = %d, want 100", got)
+	}
+	if got := m.Int("slow"); got != 0 {
+		t.Errorf("Int of an invalid value = %d, want 0", got)
+	}
+	if got := m.Int("missing"); got != 0 {
+		t.Errorf("Int of a missing tag = %d, want 0", got)
+	}
+	want := time.Date(2017, time.October, 5, 23, 36, 12, 675e6, time.UTC)
+	if got := m.Time("tmi-sent-ts"); !got.Equal(want) {
+		t.Errorf("Time(tmi-sent-ts) = %s, want %s", got, want)
+	}
+	if got := m.Time("missing"); !got.IsZero() {
+		t.Errorf("Time of a missing tag = %s, want the zero time", got)
+	}
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// textCommand is a command answered with a fixed response
+type textCommand struct {
+	response   string
+	permission Permission
+}
+
+// AddTextCommand adds !name in every channel, answered by saying response. Commands registered
+// with a handler take precedence over text commands of the same name, and cooldowns apply as they
+// do to them. Text commands are saved to Store when it's set.
+//
+// The response can contain {user}, {channel} and {uptime}, which are replaced by the user who ran
+// the command, the channel and the bot's uptime when it runs.
+func (bb *BasicBot) AddTextCommand(name, response string) {
+	bb.AddTextCommandFor(PermEveryone, name, response)
+}
+
+// AddTextCommandFor is like AddTextCommand, but only users with at least the given permission can
+// run the command.
+func (bb *BasicBot) AddTextCommandFor(permission Permission, name, response string) {
+	bb.dispatcher().setTextCommand(name, textCommand{response, permission})
+
+	bb.saveState()
+}
+
+// RemoveTextCommand removes the text command !name, if there is one
+func (bb *BasicBot) RemoveTextCommand(name string) {
+	bb.dispatcher().removeTextCommand(name)
+
+	bb.saveState()
+}
+
+// handler returns a CommandHandler saying the expanded response
+func (c textCommand) handler() CommandHandler {
+	return func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		return bb.Say(msg.Channel, expandResponse(c.response, bb, msg))
+	}
+}
+
+func expandResponse(response string, bb *BasicBot, msg *Message) string {
+	return strings.NewReplacer(
+		"{user}", msg.User,
+		"{channel}", msg.Channel,
+		"{uptime}", bb.Uptime().Round(time.Second).String(),
+	).Replace(response)
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAddTextCommand(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+	b.setConn(client)
+	defer b.stopWriting()
+
+	b.AddTextCommand("Discord", "{user}, join {channel}'s discord!")
+	b.AddTextCommandFor(PermModerator, "secret", "mods only")
+	b.SetCommandCooldown("discord", time.Minute)
+
+	go func() {
+		handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!secret"}, b)
+		handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!discord"}, b)
+		handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!discord"}, b)
+		handleChatPrivMsg(context.Background(), &Message{User: "owner", Channel: "owner", Text: "!secret"}, b)
+	}()
+
+	r := bufio.NewReader(server)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, want := range []string{
+		"PRIVMSG #owner :viewer, join owner's discord!\r\n",
+		"PRIVMSG #owner :mods only\r\n",
+	} {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != want {
+			t.Errorf("got %q, want %q", line, want)
+		}
+	}
+}
+
+func TestTextCommandPrecedence(t *testing.T) {
+	b := NewBot("owner", "bot")
+	b.Logger = NopLogger{}
+
+	b.AddTextCommand("hi", "text")
+	ran := false
+	b.RegisterCommand("hi", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		ran = true
+		return nil
+	})
+	handleChatPrivMsg(context.Background(), &Message{User: "viewer", Channel: "owner", Text: "!hi"}, b)
+	if !ran {
+		t.Error("the registered handler didn't take precedence over the text command")
+	}
+
+	b.RemoveTextCommand("HI")
+	if _, ok := b.dispatcher().textCommands["hi"]; ok {
+		t.Error("text command still registered after RemoveTextCommand")
+	}
+}
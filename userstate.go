@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+)
+
+// UserState is the bot's own state in a channel, as sent by USERSTATE when it joins and after
+// each message it sends. The GLOBALUSERSTATE sent on login is kept under an empty Channel.
+type UserState struct {
+	Channel     string
+	DisplayName string
+	// Color is the bot's chat color, like #1E90FF, empty if it was never set
+	Color string
+	// Badges are the bot's badges in the channel, like moderator/1
+	Badges []string
+	// EmoteSets are the ids of the emote sets the bot can use
+	EmoteSets []string
+	// Permission is the bot's level in the channel, from its badges
+	Permission Permission
+}
+
+// userStates keeps the latest UserState of every channel
+type userStates struct {
+	mu     sync.Mutex
+	states map[string]UserState
+}
+
+// UserState returns the bot's own state in channel as last sent by Twitch, or the global state for
+// an empty channel. It's the zero UserState until Twitch has sent one, which needs the
+// twitch.tv/tags and twitch.tv/commands capabilities.
+func (bb *BasicBot) UserState(channel string) UserState {
+	bb.userStates.mu.Lock()
+	defer bb.userStates.mu.Unlock()
+
+	state, ok := bb.userStates.states[channel]
+	if !ok {
+		state.Channel = channel
+	}
+	return state
+}
+
+// IsMod reports whether the bot can moderate channel, as a moderator or the broadcaster
+func (bb *BasicBot) IsMod(channel string) bool {
+	return bb.selfPermission(channel) >= PermModerator
+}
+
+func handleUserState(m *Message, bb *BasicBot) {
+	state := UserState{
+		Channel:     m.Channel,
+		DisplayName: m.Tags["display-name"],
+		Color:       m.Tags["color"],
+		Badges:      splitTag(m.Tags, "badges"),
+		EmoteSets:   splitTag(m.Tags, "emote-sets"),
+		Permission:  m.Permission(),
+	}
+
+	bb.userStates.mu.Lock()
+	defer bb.userStates.mu.Unlock()
+
+	if bb.userStates.states == nil {
+		bb.userStates.states = make(map[string]UserState)
+	}
+	bb.userStates.states[m.Channel] = state
+}
+
+// splitTag splits a comma-separated tag, returning nil when it's empty
+func splitTag(tags map[string]string, key string) []string {
+	if tags[key] == "" {
+		return nil
+	}
+	return strings.Split(tags[key], ",")
+}
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUserState(t *testing.T) {
+	b := &BasicBot{Name: "bot", Logger: NopLogger{}}
+	if b.IsMod("dallas") {
+		t.Error("moderator before any USERSTATE")
+	}
+
+	for _, line := range []string{
+		"@badge-info=;badges=;color=#0D4200;display-name=Bot;emote-sets=0,33,50;user-id=123;user-type= :tmi.twitch.tv GLOBALUSERSTATE",
+		"@badge-info=;badges=;color=#0D4200;display-name=Bot;emote-sets=0,33,50;mod=0;subscriber=0;user-type= :tmi.twitch.tv USERSTATE #dallas",
+		"@badge-info=;badges=moderator/1;color=#0D4200;display-name=Bot;emote-sets=0,33,50;mod=1;subscriber=0;user-type=mod :tmi.twitch.tv USERSTATE #dallas",
+	} {
+		m, err := ParseMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleUserState(m, b)
+	}
+
+	want := UserState{Channel: "dallas", DisplayName: "Bot", Color: "#0D4200", Badges: []string{"moderator/1"}, EmoteSets: []string{"0", "33", "50"}, Permission: PermModerator}
+	if got := b.UserState("dallas"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if !b.IsMod("dallas") {
+		t.Error("not a moderator after the USERSTATE")
+	}
+	if b.IsMod("other") {
+		t.Error("moderator in a channel without a USERSTATE")
+	}
+	if got := b.UserState("").DisplayName; got != "Bot" {
+		t.Errorf("global display name %q, want Bot", got)
+	}
+}
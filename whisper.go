@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Twitch's limits on whispers sent by a bot that isn't verified
+const (
+	whispersPerSecond = 3
+	whispersPerMinute = 100
+)
+
+// Whisper sends msg privately to user, with the Helix API. The bot's token must have the
+// user:manage:whispers scope, and the bot's account a verified phone number.
+//
+// Whispers are rate limited separately from Say, blocking while the bot is over the limit. Twitch
+// also caps the number of distinct recipients per day for bots that aren't verified.
+func (bb *BasicBot) Whisper(user, msg string) error {
+	user = strings.ToLower(strings.TrimPrefix(user, "@"))
+	if user == "" {
+		return errors.New("BasicBot.Whisper: user was empty")
+	}
+	if msg == "" {
+		return fmt.Errorf("BasicBot.Whisper: %w", ErrEmptyMessage)
+	}
+	if strings.ContainsAny(user, " \r\n\x00") {
+		return fmt.Errorf("BasicBot.Whisper: invalid user %q", user)
+	}
+	if err := checkLine(msg); err != nil {
+		return fmt.Errorf("BasicBot.Whisper: %w", err)
+	}
+	if bb.Anonymous {
+		return fmt.Errorf("BasicBot.Whisper: %w", ErrAnonymous)
+	}
+	if bb.DryRun {
+		bb.logger().Infof("dry run, not whispering to %s: %s", user, msg)
+		return nil
+	}
+
+	bb.whisperSecond.wait(whispersPerSecond, time.Second)
+	bb.whisperMinute.wait(whispersPerMinute, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), helixTimeout)
+	defer cancel()
+
+	helix := bb.Helix()
+	ids, err := helix.userIDs(ctx, strings.ToLower(bb.Name), user)
+	if err != nil {
+		return fmt.Errorf("BasicBot.Whisper: %w", err)
+	}
+	if err := helix.SendWhisper(ctx, ids[0], ids[1], msg); err != nil {
+		return fmt.Errorf("BasicBot.Whisper: %w", err)
+	}
+	return nil
+}
+
+// SendWhisper whispers message from the user with the id fromUserID, who the token must belong
+// to, to the user with the id toUserID. The token must have the user:manage:whispers scope.
+func (h *HelixClient) SendWhisper(ctx context.Context, fromUserID, toUserID, message string) error {
+	query := url.Values{"from_user_id": {fromUserID}, "to_user_id": {toUserID}}
+	body := struct {
+		Message string `json:"message"`
+	}{message}
+	if err := h.do(ctx, http.MethodPost, "/whispers", query, body, nil); err != nil {
+		return missingScope(err, "user:manage:whispers")
+	}
+	return nil
+}
+
+func handleWhisper(m *Message, bb *BasicBot) {
+	bb.logger().Infof("whisper from %s: %s", m.User, m.Text)
+	if bb.OnWhisper != nil {
+		bb.OnWhisper(m)
+	}
+}
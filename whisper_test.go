@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWhisper(t *testing.T) {
+	var got struct {
+		query   url.Values
+		message string
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"1","login":"bot"},{"id":"2","login":"ronni"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/whispers":
+			var body struct {
+				Message string `json:"message"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			got.query, got.message = r.URL.Query(), body.Message
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "Bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	if err := b.Whisper("@Ronni", "hello there"); err != nil {
+		t.Fatal(err)
+	}
+	if got.query.Get("from_user_id") != "1" || got.query.Get("to_user_id") != "2" || got.message != "hello there" {
+		t.Errorf("whispered %q with %v", got.message, got.query)
+	}
+}
+
+func TestWhisperMissingScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/whispers" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Missing scope: user:manage:whispers"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"1","login":"bot"},{"id":"2","login":"ronni"}]}`))
+	}))
+	defer srv.Close()
+
+	b := &BasicBot{Name: "bot", Credentials: &OAuthCred{Password: "oauth:token", ClientID: "client"}, HelixURL: srv.URL, Logger: NopLogger{}}
+	if err := b.Whisper("ronni", "hi"); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("got %v, want ErrMissingScope", err)
+	}
+}
+
+func TestHandleWhisper(t *testing.T) {
+	line := "@badges=;color=;display-name=Ronni;emotes=;message-id=6;thread-id=12345_67890;turbo=0;user-id=12345;user-type= :ronni!ronni@ronni.tmi.twitch.tv WHISPER mybot :psst, hi"
+	msg, err := ParseMessage(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &BasicBot{Logger: NopLogger{}}
+	var got *Message
+	b.OnWhisper = func(msg *Message) { got = msg }
+	handleWhisper(msg, b)
+
+	if got == nil || got.User != "ronni" || got.Text != "psst, hi" {
+		t.Errorf("got %+v", got)
+	}
+}
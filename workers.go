@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// commandQueueSize is how many commands can wait for each worker before more are dropped
+const commandQueueSize = 16
+
+// commandPool runs commands on a fixed number of workers, off the goroutine reading chat. Each
+// user's commands go to the same worker, so they still run in the order they were sent.
+type commandPool struct {
+	queues []chan func()
+}
+
+// newCommandPool starts workers goroutines, which run until stop is called
+func newCommandPool(workers int) *commandPool {
+	p := &commandPool{queues: make([]chan func(), workers)}
+	for i := range p.queues {
+		queue := make(chan func(), commandQueueSize)
+		p.queues[i] = queue
+		go func() {
+			for job := range queue {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues job on the worker of user. It returns false without queueing it when that worker
+// is too far behind.
+func (p *commandPool) submit(user string, job func()) bool {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(user)))
+
+	select {
+	case p.queues[h.Sum32()%uint32(len(p.queues))] <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop lets the workers exit once they've run the commands already queued
+func (p *commandPool) stop() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+}
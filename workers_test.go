@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandWorkers(t *testing.T) {
+	conn := newFakeConn(
+		":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!slow\r\n",
+		":other!other@other.tmi.twitch.tv PRIVMSG #channel :still reading\r\n",
+	)
+	logger := &recordLogger{}
+	b := NewBot("channel", "bot")
+	b.Logger = logger
+	b.CommandWorkers = 2
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	b.RegisterCommand("slow", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		<-release
+		close(done)
+		return nil
+	})
+	b.setConn(conn)
+
+	result := make(chan error, 1)
+	go func() { result <- b.HandleChat() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !logger.contains("other: still reading") {
+		if time.Now().After(deadline) {
+			t.Fatal("message after the slow command was not read while it ran")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	<-done
+	b.Disconnect()
+	<-result
+}
+
+func TestCommandPoolFull(t *testing.T) {
+	p := newCommandPool(1)
+	defer p.stop()
+
+	started, block := make(chan struct{}), make(chan struct{})
+	defer close(block)
+	p.submit("viewer", func() {
+		close(started)
+		<-block
+	})
+	<-started
+
+	// the worker is busy, so fill its queue then one more
+	for i := 0; i < commandQueueSize; i++ {
+		if !p.submit("viewer", func() {}) {
+			t.Fatalf("command %d dropped before the queue was full", i)
+		}
+	}
+	if p.submit("viewer", func() {}) {
+		t.Error("command queued on a full worker")
+	}
+}
+
+func (l *recordLogger) contains(s string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
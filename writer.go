@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// number of lines that can wait for the writer before send blocks
+const outgoingBuffer = 64
+
+// how long sendWait waits for a line to be written
+const sendWaitTimeout = 5 * time.Second
+
+// how long Disconnect waits for the PART lines to be written before closing the connection
+const partFlushTimeout = time.Second
+
+// ircConn is the part of net.Conn the bot uses, so tests can stand in for the server
+type ircConn interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// outbound is a fully-formed line waiting to be written to the connection
+type outbound struct {
+	line string
+	// done receives the result of the write when set
+	done chan error
+	// queued is when a chat line started waiting in the held lines
+	queued time.Time
+}
+
+// setConn makes conn the bot's connection and starts its writer goroutine, with a queue of its
+// own so nothing queued for an earlier connection is written to it. The earlier connection's
+// writer is stopped first, and has dropped its held lines once it's done.
+func (bb *BasicBot) setConn(conn ircConn) {
+	bb.stopWriting()
+	bb.connMu.Lock()
+	writerDone := bb.writerDone
+	bb.connMu.Unlock()
+	if writerDone != nil {
+		<-writerDone
+	}
+
+	bb.connMu.Lock()
+	defer bb.connMu.Unlock()
+
+	bb.conn = conn
+	bb.closed = false
+	bb.startTime = time.Now()
+	bb.outgoing = make(chan outbound, outgoingBuffer)
+	bb.stopWriter = make(chan struct{})
+	bb.writerDone = make(chan struct{})
+	go bb.writeLoop(conn, bb.outgoing, bb.stopWriter, bb.writerDone)
+}
+
+// stopWriting stops the writer goroutine of the current connection, if it's running. The lines
+// still waiting to be written are dropped.
+func (bb *BasicBot) stopWriting() {
+	bb.connMu.Lock()
+	defer bb.connMu.Unlock()
+
+	if bb.stopWriter != nil {
+		close(bb.stopWriter)
+		bb.stopWriter = nil
+	}
+}
+
+// writeLoop is the only goroutine writing to conn, so every line is written whole and in the order
+// it was queued. Chat lines wait in bb.held to be spaced by MsgRate, or while sending is paused
+// for Twitch's rate limit, without holding up the other lines. Lines still waiting when it stops
+// are dropped along with the connection.
+func (bb *BasicBot) writeLoop(conn ircConn, queue <-chan outbound, stop, done chan struct{}) {
+	defer close(done)
+	// the held lines were meant for conn, so they go with it
+	defer bb.held.clear()
+
+	// no chat line is written before next, to space them by MsgRate
+	var next time.Time
+	for {
+		var wait <-chan time.Time
+		out, delay, ok, expired := bb.held.take(next, bb.chatSlot)
+		for _, line := range expired {
+			bb.logger().Errorf("dropping %q, the channel's chat modes kept the bot from talking for %s", line.line, roomWaitTimeout)
+			if line.done != nil {
+				line.done <- ErrRoomRestricted
+			}
+		}
+		if ok {
+			bb.write(conn, out)
+			next = time.Now().Add(bb.MsgRate)
+			continue
+		} else if delay > 0 {
+			wait = time.After(delay)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-wait:
+		case <-bb.held.wakeup():
+		case out := <-queue:
+			if isChatLine(out.line) {
+				if !bb.held.push(out) {
+					bb.logger().Errorf("dropping %q, %d chat lines are already waiting", out.line, maxHeldLines)
+					if out.done != nil {
+						out.done <- errors.New("too many chat lines waiting to be sent")
+					}
+				}
+			} else {
+				bb.write(conn, out)
+			}
+		}
+	}
+}
+
+// write writes out to conn, reporting the result to out.done when it's set
+func (bb *BasicBot) write(conn ircConn, out outbound) {
+	conn.SetWriteDeadline(time.Now().Add(bb.writeTimeout()))
+	_, err := conn.Write([]byte(out.line))
+	if err != nil {
+		bb.logger().Errorf("writing %q: %s", out.line, err)
+	}
+	if out.done != nil {
+		out.done <- err
+	}
+}
+
+// send queues line, which must end in "\r\n", to be written to the connection. It fails with
+// ErrNotConnected when there's no writer to write it.
+func (bb *BasicBot) send(line string) error {
+	_, err := bb.enqueue(outbound{line: line})
+	return err
+}
+
+// enqueue queues out for the writer of the current connection, returning the channel closed once
+// that writer stops. It blocks while the queue is full, but not once the writer has stopped.
+func (bb *BasicBot) enqueue(out outbound) (<-chan struct{}, error) {
+	bb.connMu.Lock()
+	queue, writerDone := bb.outgoing, bb.writerDone
+	bb.connMu.Unlock()
+
+	if queue == nil {
+		return nil, ErrNotConnected
+	}
+	select {
+	case <-writerDone:
+		return nil, ErrNotConnected
+	default:
+	}
+	select {
+	case queue <- out:
+		return writerDone, nil
+	case <-writerDone:
+		return nil, ErrNotConnected
+	}
+}
+
+// sendWait queues line like send, then waits until it has been written to the connection
+func (bb *BasicBot) sendWait(line string) error {
+	done := make(chan error, 1)
+	writerDone, err := bb.enqueue(outbound{line: line, done: done})
+	if err != nil {
+		return fmt.Errorf("BasicBot.sendWait: %w", err)
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-writerDone:
+		return errors.New("BasicBot.sendWait: connection closed before the line was written")
+	case <-time.After(sendWaitTimeout):
+		return errors.New("BasicBot.sendWait: timed out waiting for the line to be written")
+	}
+}
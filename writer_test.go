@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentSay(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	b := &BasicBot{Channel: "channel", MessageLimit: 1000, Logger: NopLogger{}}
+	b.setConn(client)
+	defer b.stopWriting()
+
+	const n = 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b.Say("channel", fmt.Sprintf("message %d", i))
+		}(i)
+	}
+
+	seen := make(map[string]bool)
+	r := bufio.NewReader(server)
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		var id int
+		if _, err := fmt.Sscanf(line, "PRIVMSG #channel :message %d\r\n", &id); err != nil {
+			t.Fatalf("interleaved or malformed line %q", line)
+		}
+		seen[line] = true
+	}
+	wg.Wait()
+
+	if len(seen) != n {
+		t.Errorf("received %d distinct lines, want %d", len(seen), n)
+	}
+}
+
+func TestMsgRateOnlyThrottlesSending(t *testing.T) {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, ":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!count\r\n")
+	}
+	conn := newFakeConn(lines...)
+	b := NewBot("channel", "bot")
+	b.MsgRate = 100 * time.Millisecond
+	b.Logger = NopLogger{}
+
+	var count int32
+	b.RegisterCommand("count", func(ctx context.Context, bb *BasicBot, msg *Message, cmd *Command) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+
+	start := time.Now()
+	b.setConn(conn)
+	go b.HandleChat()
+	defer b.Disconnect()
+	for atomic.LoadInt32(&count) < 50 {
+		if time.Since(start) > time.Second {
+			t.Fatalf("only %d of 50 messages handled after a second", atomic.LoadInt32(&count))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start = time.Now()
+	b.Say("channel", "one")
+	b.Say("channel", "two")
+	conn.waitFor(t, "PRIVMSG #channel :two\r\n")
+	if elapsed := time.Since(start); elapsed < b.MsgRate {
+		t.Errorf("two messages sent %s apart, want at least MsgRate %s", elapsed, b.MsgRate)
+	}
+	if !strings.Contains(conn.written(), "PRIVMSG #channel :one\r\n") {
+		t.Errorf("first message not sent, wrote %q", conn.written())
+	}
+}
+
+func TestQueueDroppedWithConnection(t *testing.T) {
+	b := &BasicBot{Channel: "channel", Logger: NopLogger{}}
+	old := newFakeConn()
+	b.setConn(old)
+	// holds chat lines back, so "stale" is still waiting when the connection goes
+	b.held.pause("channel", 50*time.Millisecond)
+	if err := b.Say("channel", "stale"); err != nil {
+		t.Fatal(err)
+	}
+	b.Disconnect()
+
+	conn := newFakeConn()
+	b.setConn(conn)
+	defer b.Disconnect()
+	if err := b.Say("channel", "fresh"); err != nil {
+		t.Fatal(err)
+	}
+	conn.waitFor(t, "PRIVMSG #channel :fresh\r\n")
+	if strings.Contains(old.written()+conn.written(), "stale") {
+		t.Errorf("line queued for the old connection was written, wrote %q", conn.written())
+	}
+}
+
+func TestSendWithoutWriter(t *testing.T) {
+	b := &BasicBot{Logger: NopLogger{}}
+	if err := b.send("PING :tmi.twitch.tv\r\n"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("send before setConn returned %v, want ErrNotConnected", err)
+	}
+
+	b.setConn(newFakeConn())
+	b.stopWriting()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// more than the queue holds, which would block without a writer
+		for i := 0; i <= outgoingBuffer; i++ {
+			b.send("PING :tmi.twitch.tv\r\n")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("send blocked after the writer stopped")
+	}
+	if err := b.send("PING :tmi.twitch.tv\r\n"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("send after stopWriting returned %v, want ErrNotConnected", err)
+	}
+}